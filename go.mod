@@ -2,14 +2,16 @@ module github.com/lunashade/lang
 
 go 1.18
 
-require gotest.tools v2.2.0+incompatible
+require (
+	github.com/google/go-cmp v0.5.8
+	github.com/llir/llvm v0.3.4
+	gotest.tools v2.2.0+incompatible
+)
 
 require (
-	github.com/google/go-cmp v0.5.8 // indirect
 	github.com/kr/pretty v0.2.0 // indirect
 	github.com/kr/text v0.2.0 // indirect
 	github.com/llir/ll v0.0.0-20210719001141-246f2b6b1fa9 // indirect
-	github.com/llir/llvm v0.3.4 // indirect
 	github.com/mewmew/float v0.0.0-20201204173432-505706aa38fa // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	golang.org/x/mod v0.4.2 // indirect
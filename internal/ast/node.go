@@ -1,5 +1,7 @@
 package ast
 
+import "github.com/lunashade/lang/internal/token"
+
 type AST interface {
 	node()
 }
@@ -9,8 +11,18 @@ type Root struct {
 }
 
 type Function struct {
-	Name AST
-	Body []AST
+	Doc    string // text of the doc comments, without "///"
+	Name   AST
+	Params []*Param
+	Ret    *Ident // return type, "()" for unit, nil for the default i32
+	Body   []AST
+}
+
+// Param is a parameter of a function, as "Name: Type".
+type Param struct {
+	Name string
+	Type *Ident
+	Pos  token.Pos // position of Name
 }
 
 type Block struct {
@@ -19,6 +31,7 @@ type Block struct {
 
 func (*Root) node()      {}
 func (*Function) node()  {}
+func (*Param) node()     {}
 func (*Block) node()     {}
 func (*Block) exprNode() {}
 
@@ -35,11 +48,54 @@ type Semi struct {
 	Expr AST
 }
 
+// Let declares a variable in the enclosing block, as "let Name = Value;".
+type Let struct {
+	Name  string
+	Value AST
+	Pos   token.Pos // position of Name
+}
+
+// Return returns from the function, as "return Value;".
+// Value is nil for "return;".
+type Return struct {
+	Value AST
+	Pos   token.Pos // position of "return"
+}
+
+// Break exits the innermost loop or the labelled one, as "break 'label Value;".
+// Label and Value are optional.
+type Break struct {
+	Label *Ident
+	Value AST
+	Pos   token.Pos // position of "break"
+}
+
+// Continue goes to the next iteration of the loop, as "continue 'label;".
+type Continue struct {
+	Label *Ident
+	Pos   token.Pos // position of "continue"
+}
+
+// BadStmt is a placeholder for a statement with syntax errors.
+type BadStmt struct {
+	From, To token.Pos
+}
+
 func (*ExprStmt) node() {}
 func (*Semi) node()     {}
+func (*Let) node()      {}
+func (*Return) node()   {}
+func (*Break) node()    {}
+func (*Continue) node() {}
+func (*BadStmt) node()  {}
 
 func (*ExprStmt) stmtNode() {}
 func (*Semi) stmtNode()     {}
+func (*Let) stmtNode()      {}
+func (*Return) stmtNode()   {}
+func (*Break) stmtNode()    {}
+func (*Continue) stmtNode() {}
+func (*BadStmt) stmtNode()  {}
 
 // expressions
 type Expr interface {
@@ -47,24 +103,88 @@ type Expr interface {
 	exprNode()
 }
 
+// Int is an integer literal.
+// Value holds the bits of the literal, which may exceed int64 for u64.
 type Int struct {
-	Value int64
+	Value  int64
+	Suffix string // width suffix such as "u8", empty for the default i32
+	Pos    token.Pos
+}
+
+type Float struct {
+	Value float64
+}
+
+type String struct {
+	Value string
+}
+
+// Char is a character literal, whose value is the code point.
+// It is an i32, or a u8 if Byte as b'a'.
+type Char struct {
+	Value rune
+	Byte  bool
 }
 
 type Ident struct {
 	Name string
+	Pos  token.Pos
 }
 
 type BinOp struct {
 	Kind     BinOpKind
 	LHS, RHS AST
+	Pos      token.Pos // position of the operator
 }
 type BinOpKind int
 
+// UnaryOp applies the prefix operator to Expr.
+type UnaryOp struct {
+	Kind UnaryOpKind
+	Expr AST
+	Pos  token.Pos // position of the operator
+}
+type UnaryOpKind int
+
+// Cast converts Expr to the named type, as "Expr as Type".
+type Cast struct {
+	Expr AST
+	Type string
+	Pos  token.Pos // position of "as"
+}
+
+// Call calls the named function, as "Name(Args...)".
+type Call struct {
+	Name string
+	Args []AST
+	Pos  token.Pos // position of Name
+}
+
 type IfExpr struct {
 	Cond AST
 	Then AST
 	Els  AST
+	Pos  token.Pos // position of "if"
+}
+
+// While repeats Body while Cond is true, as "'label: while Cond Body".
+type While struct {
+	Label *Ident // optional, named with the quote as "'label"
+	Cond  AST
+	Body  AST
+	Pos   token.Pos // position of "while"
+}
+
+// Loop repeats Body until break, as "'label: loop Body".
+// The value of break is the value of the loop.
+type Loop struct {
+	Label *Ident // optional, named with the quote as "'label"
+	Body  AST
+}
+
+// BadExpr is a placeholder for an expression with syntax errors.
+type BadExpr struct {
+	From, To token.Pos
 }
 
 const (
@@ -79,14 +199,52 @@ const (
 	GreaterThan
 	LessThanOrEqual
 	GreaterThanOrEqual
+	LogicalAnd // &&, evaluating RHS only if LHS is true
+	LogicalOr  // ||, evaluating RHS only if LHS is false
 )
 
-func (*Int) node()    {}
-func (*Ident) node()  {}
-func (*BinOp) node()  {}
-func (*IfExpr) node() {}
+const (
+	Neg        UnaryOpKind = iota + 1 // -x
+	Not                               // !x
+	Complement                        // ~x
+)
+
+func (k UnaryOpKind) String() string {
+	switch k {
+	case Neg:
+		return "-"
+	case Not:
+		return "!"
+	case Complement:
+		return "~"
+	}
+	return "?"
+}
 
-func (*Int) exprNode()    {}
-func (*Ident) exprNode()  {}
-func (*BinOp) exprNode()  {}
-func (*IfExpr) exprNode() {}
+func (*Int) node()     {}
+func (*Float) node()   {}
+func (*String) node()  {}
+func (*Char) node()    {}
+func (*Ident) node()   {}
+func (*BinOp) node()   {}
+func (*UnaryOp) node() {}
+func (*Cast) node()    {}
+func (*Call) node()    {}
+func (*IfExpr) node()  {}
+func (*While) node()   {}
+func (*Loop) node()    {}
+func (*BadExpr) node() {}
+
+func (*Int) exprNode()     {}
+func (*Float) exprNode()   {}
+func (*String) exprNode()  {}
+func (*Char) exprNode()    {}
+func (*Ident) exprNode()   {}
+func (*BinOp) exprNode()   {}
+func (*UnaryOp) exprNode() {}
+func (*Cast) exprNode()    {}
+func (*Call) exprNode()    {}
+func (*IfExpr) exprNode()  {}
+func (*While) exprNode()   {}
+func (*Loop) exprNode()    {}
+func (*BadExpr) exprNode() {}
@@ -9,14 +9,15 @@ import (
 	"github.com/lunashade/lang/internal/token"
 )
 
-func Run(r io.Reader, w io.Writer) {
-	tokens := token.Lex(r)
-	node, err := parse.Run(tokens)
+func Run(r io.Reader, w io.Writer) error {
+	tokens := token.NewSourceStream(token.NewScanner(r))
+	node, err := parse.RunStream(tokens)
 	if err != nil {
-		panic(fmt.Errorf("parse error: %w", err))
+		return fmt.Errorf("parse error: %w", err)
 	}
 	err = gen.Run(w, node)
 	if err != nil {
-		panic(fmt.Errorf("codegen error: %w", err))
+		return fmt.Errorf("codegen error: %w", err)
 	}
+	return nil
 }
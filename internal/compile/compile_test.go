@@ -14,5 +14,239 @@ main(){
 
 func TestCompile(t *testing.T) {
 	var buf bytes.Buffer
-	Run(strings.NewReader(sample), &buf)  // check it won't panic
+	if err := Run(strings.NewReader(sample), &buf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompileOutput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string // lines in the output
+	}{
+		{
+			"int width", "main(){ 255u8; 0xffff_ffff_ffff_ffffu64; 2147483647; 7 }",
+			[]string{
+				"ret i32 7",
+			},
+		},
+		{
+			"float", "main(){ (1.5 + 2.0 * 3.0 / 4.0 - 0.5 < 1.0e1) + (1.0 != 2.0) + 3.9 as i32 + 1 as f64 as i8 as i32 }",
+			[]string{
+				"fadd double 1.5, %",
+				"fmul double 2.0, 3.0",
+				"fdiv double %",
+				"fsub double %",
+				"fcmp olt double",
+				"fcmp une double 1.0, 2.0",
+				"fptosi double 0x400F333333333333 to i32",
+				"sitofp i32 1 to double",
+				"fptosi double %",
+				"sext i8 %",
+			},
+		},
+		{
+			"string", `main(){ "hi\n"; "hi\n"; "" ; 0 }`,
+			[]string{
+				`@.str.0 = private unnamed_addr constant [3 x i8] c"hi\0A"`,
+				`@.str.1 = private unnamed_addr constant [0 x i8] c""`,
+			},
+		},
+		{
+			"variable", "main(){ let x = 1; let y = 2.0; x = x + 1; x }",
+			[]string{
+				"%1 = alloca i32",
+				"%2 = alloca double",
+				"store i32 1, i32* %1",
+				"store double 2.0, double* %2",
+				"load i32, i32* %1",
+			},
+		},
+		{
+			"call", "main(){ half(7.0) as i32 } half(x: f64) -> f64 { x / 2.0 }",
+			[]string{
+				"call double @half(double 7.0)",
+				"define double @half(double %x) {",
+				"store double %x, double* %1",
+			},
+		},
+		{
+			"return", "f(x: i32) -> () { if x then { return; } else { return; }; f(x) } main(){ return 1; 2 }",
+			[]string{
+				"define void @f(i32 %x) {",
+				"ret void",
+				"unreachable",
+				"ret i32 1",
+			},
+		},
+		{
+			"unsigned", "main(){ 200u8 > 100u8; 255u8 / 2u8; 1i8 < 2i8; 9i8 / 3i8; 200u8 as i8; 0 }",
+			[]string{
+				"icmp ugt i8 200, 100",
+				"udiv i8 255, 2",
+				"icmp slt i8 1, 2",
+				"sdiv i8 9, 3",
+				"bitcast i8 200 to i8",
+			},
+		},
+		{
+			"unsigned cast", "main(){ 200u8 as i32; -1 as i64; 3000000000u32 as f64; 1.0 as u64; 2.5 as i8; 0 }",
+			[]string{
+				"zext i8 200 to i32",
+				"sext i32 -1 to i64",
+				"uitofp i32 3000000000 to double",
+				"fptoui double 1.0 to i64",
+				"fptosi double 2.5 to i8",
+			},
+		},
+		{
+			"char width", "main(){ b'a' == 97u8; 'a' == 97; 0 }",
+			[]string{
+				"icmp eq i8 97, 97",
+				"icmp eq i32 97, 97",
+			},
+		},
+		{
+			"unit call result", "nop() -> () {} main(){ nop() }",
+			[]string{
+				"call void @nop()",
+				"ret i32 0",
+			},
+		},
+		{
+			"diverging operand", "f(x: i32){ x } a(){ { return 1; } + 2 } b(){ f({ return 4; }) } c(){ if { return 7; } then 1 else 2 } d(){ { return 5; } as i64; 0 } e(){ while { return 6; } {}; 0 }",
+			[]string{
+				"ret i32 1",
+				"ret i32 4",
+				"ret i32 7",
+				"ret i32 5",
+				"ret i32 6",
+			},
+		},
+		{
+			"while", "main(){ let i = 0; while i < 3 { i = i + 1; }; i }",
+			[]string{
+				"br label %whilecond1",
+				"label %whilebody1, label %whileend1",
+				"br label %whilecond1\n\nwhileend1:",
+				"whileend1:",
+			},
+		},
+		{
+			"loop", "main(){ loop { break 5; } }",
+			[]string{
+				"br label %loop1",
+				"loopend1:",
+				"phi i32 [ 5, %loop1 ]",
+			},
+		},
+		{
+			"unary", "main(){ let x = 3; -2147483648; -x + ~x + !x + (-1.5 as i32) }",
+			[]string{
+				"sub i32 0, %",
+				"xor i32 %4, -1",
+				"icmp eq i32 %7, 0",
+				"fneg double 1.5",
+			},
+		},
+		{
+			"logical", "main(){ (1 && 2.0) + (0 || 3) }",
+			[]string{
+				"br i1 %1, label %andrhs1, label %andend1",
+				"phi i1 [ false, %0 ], [ %2, %andrhs1 ]",
+				"br i1 %5, label %orend2, label %orrhs2",
+				"phi i1 [ true, %andend1 ], [ %6, %orrhs2 ]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Run(strings.NewReader(tt.input), &buf); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			for _, line := range tt.want {
+				if !strings.Contains(out, line) {
+					t.Errorf("want %q in output:\n%s", line, out)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"lexical", `main(){ "\q" }`, `parse error: 1:9: unknown escape sequence '\q'`},
+		{"syntax", "main(){ 1 + }", "parse error: 1:13: expected expression, found '}'"},
+		{"several", "main(){ 1 + ; 2 then }", "parse error: 1:13: expected expression, found ';' (and 1 more errors)"},
+		{"trailing", "main(){ 0 } }", "parse error: 1:13: expected end of file or function, found '}'"},
+		{"return string", `main(){ "a" }`, "codegen error: 1:1: cannot return i8* value from function main returning i32"},
+		{"overflow i32", "main(){ 2147483648 }", "codegen error: 1:9: integer literal 2147483648 overflows i32"},
+		{"overflow u8", "main(){\n\t256u8;\n0}", "codegen error: 2:2: integer literal 256 overflows u8"},
+		{"overflow i64", "main(){ 0x8000_0000_0000_0000i64; 0 }", "codegen error: 1:9: integer literal 9223372036854775808 overflows i64"},
+		{"mismatched width", "main(){ 1i64 + 1 }", "codegen error: 1:14: mismatched types i64 and i32"},
+		{"mix int and float", "main(){ 1 + 1.0 }", "codegen error: 1:11: mismatched types i32 and f64"},
+		{"return float", "main(){ 1.0 }", "codegen error: 1:1: cannot return f64 value from function main returning i32"},
+		{"unknown type", "main(){ 1 as i7 }", "codegen error: 1:11: unknown type i7"},
+		{"cast string", `main(){ "a" as i32 }`, "codegen error: 1:13: cannot convert i8* value to i32"},
+		{"undeclared", "main(){ x }", "codegen error: 1:9: undeclared name x"},
+		{"out of scope", "main(){ { let x = 1; x }; x }", "codegen error: 1:27: undeclared name x"},
+		{"assign undeclared", "main(){ x = 1 }", "codegen error: 1:9: undeclared name x"},
+		{"assign type", "main(){ let x = 1; x = 1.0; 0 }", "codegen error: 1:20: cannot assign f64 value to x of type i32"},
+		{"undefined function", "main(){ f() }", "codegen error: 1:9: undefined function f"},
+		{"arity", "f(a: i32, b: i32) { a } main(){ f(1) }", "codegen error: 1:33: wrong number of arguments to f: want 2, got 1"},
+		{"argument type", "f(a: i32) { a } main(){ f(1.0) }", "codegen error: 1:25: cannot use f64 value as i32 argument a of f"},
+		{"redefined", "f(){ 0 }\nf(){ 1 }", "codegen error: 2:1: function f is already defined"},
+		{"duplicate parameter", "f(a: i32, a: i32) { a } main(){ f(1, 2) }", "codegen error: 1:11: duplicate parameter a"},
+		{"param type", "f(a: str) { 0 }", "codegen error: 1:6: unknown type str"},
+		{"return type", "f() -> f64 { 1 }", "codegen error: 1:1: cannot return i32 value from function f returning f64"},
+		{"missing return", "f() -> i32 { return; }", "codegen error: 1:14: missing return value in function f returning i32"},
+		{"missing value", "f() -> f64 { 1.0; }", "codegen error: 1:1: missing return value in function f returning f64"},
+		{"return type mismatch", "f() -> () { return 1; }", "codegen error: 1:13: cannot return i32 value from function f returning ()"},
+		{"unit operand", "main(){ { 1; } + 2 }", "codegen error: 1:16: operator is not defined on ()"},
+		{"unit argument", "f(x: i32){ x } main(){ f({ 1; }) }", "codegen error: 1:24: cannot use () value as i32 argument x of f"},
+		{"while condition", "main(){ while { 1; } {}; 0 }", "codegen error: 1:9: cannot use () value as condition"},
+		{"unit condition", "main(){ if { 1; } then 1 else 2 }", "codegen error: 1:9: cannot use () value as condition"},
+		{"unit cast", "main(){ { 1; } as i64; 0 }", "codegen error: 1:16: cannot convert () value to i64"},
+		{"unit loop operand", "main(){ loop { break; } + 1 }", "codegen error: 1:25: operator is not defined on ()"},
+		{"unit assign", "main(){ let x = 1; x = { 1; }; x }", "codegen error: 1:20: cannot assign () value to x of type i32"},
+		{"unit variable", "f() -> () {} main(){ let x = f(); 0 }", "codegen error: 1:26: cannot declare variable x of type ()"},
+		{"break outside loop", "main(){ break; 0 }", "codegen error: 1:9: break outside loop"},
+		{"continue outside loop", "main(){ continue; 0 }", "codegen error: 1:9: continue outside loop"},
+		{"undefined label", "main(){ loop { break 'x; } }", "codegen error: 1:22: undefined label 'x"},
+		{"break value in while", "main(){ while 1 { break 1; }; 0 }", "codegen error: 1:19: break with value in while loop"},
+		{"break types", "main(){ loop { if 1 then { break 1; } else { break 1.0; }; } }", "codegen error: 1:46: mismatched types i32 and f64 in loop breaks"},
+		{"negative overflow", "main(){ -2147483649 }", "codegen error: 1:10: integer literal -2147483649 overflows i32"},
+		{"negative unsigned", "main(){ -1u8; 0 }", "codegen error: 1:10: integer literal -1 overflows u8"},
+		{"negate unit", "main(){ -{ 1; } }", "codegen error: 1:9: operator - is not defined on ()"},
+		{"not unit", "main(){ !loop { break; } }", "codegen error: 1:9: operator ! is not defined on ()"},
+		{"complement float", "main(){ ~1.0; 0 }", "codegen error: 1:9: operator ~ is not defined on f64"},
+		{"logical string", `main(){ 1 && "a" }`, "codegen error: 1:11: cannot use i8* value as condition"},
+		{"logical unit", "main(){ { 1; } && 2 }", "codegen error: 1:16: cannot use () value as condition"},
+		{"logical unit rhs", "main(){ 0 || { 2; } }", "codegen error: 1:11: cannot use () value as condition"},
+		{"mixed signedness", "main(){ 1u8 + 1i8 }", "codegen error: 1:13: mismatched types u8 and i8"},
+		{"return unsigned", "f() -> i32 { 1u32 }", "codegen error: 1:1: cannot return u32 value from function f returning i32"},
+		{"byte and char", "main(){ b'a' == 'a' }", "codegen error: 1:14: mismatched types u8 and i32"},
+		{"byte range", `main(){ b'\u{3b1}' }`, `parse error: 1:9: character 'α' does not fit in a byte`},
+		{"string operand", `main(){ "a" + 1 }`, "codegen error: 1:13: mismatched types i8* and i32"},
+		{"string operands", `main(){ "a" + "b" }`, "codegen error: 1:13: operator is not defined on i8*"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Run(strings.NewReader(tt.input), &buf)
+			if err == nil {
+				t.Fatalf("want error, got nil")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("want %q, got %q", tt.want, err)
+			}
+		})
+	}
 }
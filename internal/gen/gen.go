@@ -17,12 +17,20 @@ type Generator struct {
 	m          *ir.Module
 	funcStack  Stack[ir.Func]
 	blockStack Stack[ir.Block]
-	blockCount int // counter for block id.
+	blockCount int                   // counter for block id.
+	strs       map[string]*ir.Global // string literals by value
+	funcs      map[string]*ir.Func   // functions by name
+	implicit   bool                  // current function returns 0 without value
+	scopes     Stack[scope]          // variables of the enclosing blocks
+	loops      Stack[loop]           // enclosing loops
+	allocas    int                   // number of allocas in the entry block
 }
 
 func Run(w io.Writer, tree ast.AST) error {
 	g := &Generator{
-		m: ir.NewModule(),
+		m:     ir.NewModule(),
+		strs:  make(map[string]*ir.Global),
+		funcs: make(map[string]*ir.Func),
 	}
 	if err := g.walk(tree); err != nil {
 		return err
@@ -34,6 +42,12 @@ func Run(w io.Writer, tree ast.AST) error {
 func (g *Generator) walk(node ast.AST) error {
 	switch nd := node.(type) {
 	case *ast.Root:
+		// declare every function first, so that calls may refer to later ones
+		for _, node := range nd.Nodes {
+			if err := g.declareFunc(node.(*ast.Function)); err != nil {
+				return err
+			}
+		}
 		for _, node := range nd.Nodes {
 			err := g.walk(node)
 			if err != nil {
@@ -43,26 +57,29 @@ func (g *Generator) walk(node ast.AST) error {
 		return nil
 	case *ast.Function:
 		name := nd.Name.(*ast.Ident)
-		ty := types.I32
-		fn := g.m.NewFunc(name.Name, ty)
+		fn := g.funcs[name.Name]
 		g.funcStack.Push(fn)
+		g.implicit = nd.Ret == nil
 
 		blk := g.funcStack.Top().NewBlock("")
 		g.blockCount = 0
+		g.allocas = 0
 		g.blockStack.Push(blk)
-		var val value.Value
-		for _, node := range nd.Body {
-			var err error
-			stmt := node.(ast.Stmt)
-			val, err = g.stmt(stmt)
-			if err != nil {
-				return err
-			}
+		g.pushScope()
+		// parameters are variables initialized with the arguments
+		for i, param := range fn.Params {
+			slot := g.declare(nd.Params[i].Name, param.Typ)
+			blk.NewStore(param, slot)
 		}
-		if val != nil {
-			g.blockStack.Top().NewRet(val)
-		} else {
-			g.blockStack.Top().NewRet(constant.NewInt(ty, 0))
+		val, err := g.stmts(nd.Body)
+		if err != nil {
+			return err
+		}
+		g.popScope()
+		if !g.terminated() {
+			if err := g.ret(val); err != nil {
+				return fmt.Errorf("%s: %w", name.Pos, err)
+			}
 		}
 		g.blockStack.Pop()
 		g.funcStack.Pop()
@@ -70,6 +87,82 @@ func (g *Generator) walk(node ast.AST) error {
 	return nil
 }
 
+// declareFunc adds the function with its signature to the module.
+func (g *Generator) declareFunc(nd *ast.Function) error {
+	name := nd.Name.(*ast.Ident)
+	if _, ok := g.funcs[name.Name]; ok {
+		return fmt.Errorf("%s: function %s is already defined", name.Pos, name.Name)
+	}
+	var ret types.Type = types.I32
+	if nd.Ret != nil && nd.Ret.Name == "()" {
+		ret = types.Void
+	} else if nd.Ret != nil {
+		var err error
+		ret, err = typeByName(nd.Ret.Name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", nd.Ret.Pos, err)
+		}
+	}
+	params := make([]*ir.Param, len(nd.Params))
+	for i, param := range nd.Params {
+		for _, prev := range nd.Params[:i] {
+			if prev.Name == param.Name {
+				return fmt.Errorf("%s: duplicate parameter %s", param.Pos, param.Name)
+			}
+		}
+		ty, err := typeByName(param.Type.Name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", param.Type.Pos, err)
+		}
+		params[i] = ir.NewParam(param.Name, ty)
+	}
+	g.funcs[name.Name] = g.m.NewFunc(name.Name, ret, params...)
+	return nil
+}
+
+// stmts generates the statements, and returns the value of the last one.
+// Statements after a terminator such as return are unreachable and skipped.
+func (g *Generator) stmts(nodes []ast.AST) (value.Value, error) {
+	var val value.Value
+	for _, node := range nodes {
+		if g.terminated() {
+			return nil, nil
+		}
+		var err error
+		val, err = g.stmt(node.(ast.Stmt))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return val, nil
+}
+
+// terminated reports whether the current block already has a terminator.
+func (g *Generator) terminated() bool {
+	return g.blockStack.Top().Term != nil
+}
+
+// ret returns the value from the current function.
+// The value may be nil for unit functions and functions without return type.
+func (g *Generator) ret(v value.Value) error {
+	fn := g.funcStack.Top()
+	ty := fn.Sig.RetType
+	blk := g.blockStack.Top()
+	switch {
+	case types.Equal(ty, types.Void) && isUnit(v):
+		blk.NewRet(nil)
+	case isUnit(v) && g.implicit:
+		blk.NewRet(zero(ty))
+	case v == nil:
+		return fmt.Errorf("missing return value in function %s returning %s", fn.Name(), typeName(ty))
+	case !sameType(v.Type(), ty):
+		return fmt.Errorf("cannot return %s value from function %s returning %s", typeName(v.Type()), fn.Name(), typeName(ty))
+	default:
+		blk.NewRet(v)
+	}
+	return nil
+}
+
 func (g *Generator) stmt(node ast.Stmt) (value.Value, error) {
 	switch nd := node.(type) {
 	case *ast.ExprStmt:
@@ -79,6 +172,41 @@ func (g *Generator) stmt(node ast.Stmt) (value.Value, error) {
 		expr := nd.Expr.(ast.Expr)
 		_, err := g.expr(expr)
 		return nil, err
+	case *ast.Let:
+		v, err := g.expr(nd.Value.(ast.Expr))
+		if err != nil {
+			return nil, err
+		}
+		if g.terminated() {
+			return nil, nil
+		}
+		if isUnit(v) {
+			return nil, fmt.Errorf("%s: cannot declare variable %s of type ()", nd.Pos, nd.Name)
+		}
+		// declared after the value, which may refer to a shadowed variable
+		slot := g.declare(nd.Name, v.Type())
+		g.blockStack.Top().NewStore(v, slot)
+		return nil, nil
+	case *ast.Return:
+		var v value.Value
+		if nd.Value != nil {
+			var err error
+			v, err = g.expr(nd.Value.(ast.Expr))
+			if err != nil {
+				return nil, err
+			}
+			if g.terminated() {
+				return nil, nil
+			}
+		}
+		if err := g.ret(v); err != nil {
+			return nil, fmt.Errorf("%s: %w", nd.Pos, err)
+		}
+		return nil, nil
+	case *ast.Break:
+		return nil, g.brk(nd)
+	case *ast.Continue:
+		return nil, g.cont(nd)
 	default:
 		return nil, errors.New("unknown statement")
 	}
@@ -87,20 +215,39 @@ func (g *Generator) stmt(node ast.Stmt) (value.Value, error) {
 func (g *Generator) expr(node ast.Expr) (value.Value, error) {
 	switch nd := node.(type) {
 	case *ast.Int:
-		return constant.NewInt(types.I32, nd.Value), nil
+		return intLit(nd, false)
+	case *ast.Float:
+		return constant.NewFloat(types.Double, nd.Value), nil
+	case *ast.String:
+		return g.str(nd.Value), nil
+	case *ast.Char:
+		if nd.Byte {
+			return constant.NewInt(u8, int64(nd.Value)), nil
+		}
+		// i32 is wide enough for any code point
+		return constant.NewInt(types.I32, int64(nd.Value)), nil
+	case *ast.Cast:
+		return g.cast(nd)
 	case *ast.BinOp:
 		return g.binOp(nd)
-	case *ast.Block:
-		var val value.Value
-		var err error
-		for _, n := range nd.Stmts {
-			stmt := n.(ast.Stmt)
-			val, err = g.stmt(stmt)
-			if err != nil {
-				return nil, err
-			}
+	case *ast.UnaryOp:
+		return g.unaryOp(nd)
+	case *ast.Ident:
+		slot, ok := g.lookup(nd.Name)
+		if !ok {
+			return nil, fmt.Errorf("%s: undeclared name %s", nd.Pos, nd.Name)
 		}
-		return val, nil
+		return g.blockStack.Top().NewLoad(slot.ElemType, slot), nil
+	case *ast.Block:
+		g.pushScope()
+		defer g.popScope()
+		return g.stmts(nd.Stmts)
+	case *ast.Call:
+		return g.call(nd)
+	case *ast.While:
+		return g.while(nd)
+	case *ast.Loop:
+		return g.loop(nd)
 	case *ast.IfExpr:
 		g.blockCount++
 		count := g.blockCount
@@ -111,9 +258,15 @@ func (g *Generator) expr(node ast.Expr) (value.Value, error) {
 		if err != nil {
 			return nil, err
 		}
-		topBlock := g.blockStack.Pop()
+		if g.terminated() {
+			return nil, nil
+		}
 		// condV != 0 -> cast to bool
-		condV = topBlock.NewICmp(enum.IPredNE, condV, constant.NewInt(types.I32, 0))
+		condV, err = g.isTrue(condV)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", nd.Pos, err)
+		}
+		topBlock := g.blockStack.Pop()
 
 		// branch
 		thenBlock := topBlock.Parent.NewBlock(fmt.Sprintf("then%d", count))
@@ -122,6 +275,8 @@ func (g *Generator) expr(node ast.Expr) (value.Value, error) {
 		topBlock.NewCondBr(condV, thenBlock, elsBlock)
 
 		// gen then node
+		// a branch terminated by return doesn't reach the merge block
+		var incomings []*ir.Incoming
 		g.blockStack.Push(thenBlock)
 		then := nd.Then.(ast.Expr)
 		thenV, err := g.expr(then)
@@ -129,7 +284,10 @@ func (g *Generator) expr(node ast.Expr) (value.Value, error) {
 			return nil, err
 		}
 		thenBlock = g.blockStack.Pop()
-		thenBlock.NewBr(mergeBlock)
+		if thenBlock.Term == nil {
+			thenBlock.NewBr(mergeBlock)
+			incomings = append(incomings, ir.NewIncoming(thenV, thenBlock))
+		}
 
 		// gen else node
 		g.blockStack.Push(elsBlock)
@@ -137,7 +295,12 @@ func (g *Generator) expr(node ast.Expr) (value.Value, error) {
 
 		if nd.Els == nil {
 			// if else is nil, then use 0-value instead
-			elsV = constant.NewInt(types.I32, 0)
+			if !isUnit(thenV) {
+				if !types.IsInt(thenV.Type()) && !types.IsFloat(thenV.Type()) {
+					return nil, fmt.Errorf("if without else must be of number type, not %s", typeName(thenV.Type()))
+				}
+				elsV = zero(thenV.Type())
+			}
 		} else {
 			var err error
 			els := nd.Els.(ast.Expr)
@@ -147,11 +310,27 @@ func (g *Generator) expr(node ast.Expr) (value.Value, error) {
 			}
 		}
 		elsBlock = g.blockStack.Pop()
-		elsBlock.NewBr(mergeBlock)
+		if elsBlock.Term == nil {
+			elsBlock.NewBr(mergeBlock)
+			incomings = append(incomings, ir.NewIncoming(elsV, elsBlock))
+		}
 
 		// gen merge block
 		g.blockStack.Push(mergeBlock)
-		phi := mergeBlock.NewPhi(ir.NewIncoming(thenV, thenBlock), ir.NewIncoming(elsV, elsBlock))
+		if len(incomings) == 0 {
+			mergeBlock.NewUnreachable()
+			return nil, nil
+		}
+		first := incomings[0].X
+		for _, inc := range incomings[1:] {
+			if isUnit(first) != isUnit(inc.X) || !isUnit(first) && !sameType(first.Type(), inc.X.Type()) {
+				return nil, fmt.Errorf("mismatched types %s and %s in if branches", valueTypeName(first), valueTypeName(inc.X))
+			}
+		}
+		if isUnit(first) {
+			return nil, nil
+		}
+		phi := mergeBlock.NewPhi(incomings...)
 		return phi, nil
 	default:
 		return nil, errors.New("unknown expr")
@@ -159,6 +338,12 @@ func (g *Generator) expr(node ast.Expr) (value.Value, error) {
 }
 
 func (g *Generator) binOp(node *ast.BinOp) (value.Value, error) {
+	switch node.Kind {
+	case ast.Assign:
+		return g.assign(node)
+	case ast.LogicalAnd, ast.LogicalOr:
+		return g.logical(node)
+	}
 	// TODO: remove type assertion
 	// LHS, RHS must be expr so solve this in parse section
 	lhsNode := node.LHS.(ast.Expr)
@@ -166,49 +351,307 @@ func (g *Generator) binOp(node *ast.BinOp) (value.Value, error) {
 	if err != nil {
 		return nil, err
 	}
+	// an operand terminated by return leaves nothing to operate on
+	if g.terminated() {
+		return nil, nil
+	}
 	rhsNode := node.RHS.(ast.Expr)
 	rhs, err := g.expr(rhsNode)
 	if err != nil {
 		return nil, err
 	}
+	if g.terminated() {
+		return nil, nil
+	}
+	if isUnit(lhs) || isUnit(rhs) {
+		return nil, fmt.Errorf("%s: operator is not defined on ()", node.Pos)
+	}
+	if !sameType(lhs.Type(), rhs.Type()) {
+		return nil, fmt.Errorf("%s: mismatched types %s and %s", node.Pos, typeName(lhs.Type()), typeName(rhs.Type()))
+	}
+	switch {
+	case types.IsInt(lhs.Type()):
+		return g.intBinOp(node.Kind, lhs, rhs)
+	case types.IsFloat(lhs.Type()):
+		return g.floatBinOp(node.Kind, lhs, rhs)
+	}
+	return nil, fmt.Errorf("%s: operator is not defined on %s", node.Pos, typeName(lhs.Type()))
+}
 
-	switch node.Kind {
+// call calls the function with the arguments of matching types.
+func (g *Generator) call(node *ast.Call) (value.Value, error) {
+	fn, ok := g.funcs[node.Name]
+	if !ok {
+		return nil, fmt.Errorf("%s: undefined function %s", node.Pos, node.Name)
+	}
+	if len(node.Args) != len(fn.Params) {
+		return nil, fmt.Errorf("%s: wrong number of arguments to %s: want %d, got %d", node.Pos, node.Name, len(fn.Params), len(node.Args))
+	}
+	args := make([]value.Value, len(node.Args))
+	for i, arg := range node.Args {
+		v, err := g.expr(arg.(ast.Expr))
+		if err != nil {
+			return nil, err
+		}
+		if g.terminated() {
+			return nil, nil
+		}
+		if want := fn.Params[i].Typ; isUnit(v) || !sameType(v.Type(), want) {
+			return nil, fmt.Errorf("%s: cannot use %s value as %s argument %s of %s", node.Pos, valueTypeName(v), typeName(want), fn.Params[i].Name(), node.Name)
+		}
+		args[i] = v
+	}
+	return g.blockStack.Top().NewCall(fn, args...), nil
+}
+
+// logical generates && and || as 1 or 0 of i32.
+// The right operand is evaluated in its own block only if the left one
+// doesn't decide the result, which is merged by a phi.
+func (g *Generator) logical(node *ast.BinOp) (value.Value, error) {
+	g.blockCount++
+	count := g.blockCount
+	name := "and"
+	if node.Kind == ast.LogicalOr {
+		name = "or"
+	}
+	lhs, err := g.expr(node.LHS.(ast.Expr))
+	if err != nil {
+		return nil, err
+	}
+	// nothing to branch on if the left operand returns
+	if g.terminated() {
+		return nil, nil
+	}
+	lhs, err = g.isTrue(lhs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", node.Pos, err)
+	}
+	lhsBlock := g.blockStack.Pop()
+	rhsBlock := lhsBlock.Parent.NewBlock(fmt.Sprintf("%srhs%d", name, count))
+	endBlock := lhsBlock.Parent.NewBlock(fmt.Sprintf("%send%d", name, count))
+	// the result when the right operand is skipped
+	short := constant.False
+	if node.Kind == ast.LogicalAnd {
+		lhsBlock.NewCondBr(lhs, rhsBlock, endBlock)
+	} else {
+		short = constant.True
+		lhsBlock.NewCondBr(lhs, endBlock, rhsBlock)
+	}
+	incomings := []*ir.Incoming{ir.NewIncoming(short, lhsBlock)}
+
+	g.blockStack.Push(rhsBlock)
+	rhs, err := g.expr(node.RHS.(ast.Expr))
+	if err != nil {
+		return nil, err
+	}
+	if rhsBlock = g.blockStack.Top(); rhsBlock.Term == nil {
+		rhs, err = g.isTrue(rhs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", node.Pos, err)
+		}
+		rhsBlock.NewBr(endBlock)
+		incomings = append(incomings, ir.NewIncoming(rhs, rhsBlock))
+	}
+	g.blockStack.Pop()
+
+	g.blockStack.Push(endBlock)
+	phi := endBlock.NewPhi(incomings...)
+	return endBlock.NewZExt(phi, types.I32), nil
+}
+
+// unaryOp generates prefix operations.
+// Negated integer literals are constants, so that the minimum value fits its type.
+func (g *Generator) unaryOp(node *ast.UnaryOp) (value.Value, error) {
+	if lit, ok := node.Expr.(*ast.Int); ok && node.Kind == ast.Neg {
+		return intLit(lit, true)
+	}
+	v, err := g.expr(node.Expr.(ast.Expr))
+	if err != nil {
+		return nil, err
+	}
+	if g.terminated() {
+		return nil, nil
+	}
+	if isUnit(v) {
+		return nil, fmt.Errorf("%s: operator %s is not defined on ()", node.Pos, node.Kind)
+	}
+	blk := g.blockStack.Top()
+	ty := v.Type()
+	switch {
+	case node.Kind == ast.Neg && types.IsInt(ty):
+		return blk.NewSub(zero(ty), v), nil
+	case node.Kind == ast.Neg && types.IsFloat(ty):
+		return blk.NewFNeg(v), nil
+	case node.Kind == ast.Not && types.IsInt(ty):
+		return blk.NewZExt(blk.NewICmp(enum.IPredEQ, v, zero(ty)), types.I32), nil
+	case node.Kind == ast.Not && types.IsFloat(ty):
+		return blk.NewZExt(blk.NewFCmp(enum.FPredOEQ, v, zero(ty)), types.I32), nil
+	case node.Kind == ast.Complement && types.IsInt(ty):
+		return blk.NewXor(v, constant.NewInt(ty.(*types.IntType), -1)), nil
+	}
+	return nil, fmt.Errorf("%s: operator %s is not defined on %s", node.Pos, node.Kind, typeName(ty))
+}
+
+// assign stores the value to the variable, and returns the value.
+func (g *Generator) assign(node *ast.BinOp) (value.Value, error) {
+	name := node.LHS.(*ast.Ident)
+	slot, ok := g.lookup(name.Name)
+	if !ok {
+		return nil, fmt.Errorf("%s: undeclared name %s", name.Pos, name.Name)
+	}
+	v, err := g.expr(node.RHS.(ast.Expr))
+	if err != nil {
+		return nil, err
+	}
+	if g.terminated() {
+		return nil, nil
+	}
+	if isUnit(v) || !sameType(v.Type(), slot.ElemType) {
+		return nil, fmt.Errorf("%s: cannot assign %s value to %s of type %s", name.Pos, valueTypeName(v), name.Name, typeName(slot.ElemType))
+	}
+	g.blockStack.Top().NewStore(v, slot)
+	return v, nil
+}
+
+func (g *Generator) intBinOp(kind ast.BinOpKind, lhs, rhs value.Value) (value.Value, error) {
+	blk := g.blockStack.Top()
+	switch kind {
+	case ast.Add:
+		return blk.NewAdd(lhs, rhs), nil
+	case ast.Sub:
+		return blk.NewSub(lhs, rhs), nil
+	case ast.Mul:
+		return blk.NewMul(lhs, rhs), nil
+	case ast.Div:
+		if isUnsigned(lhs.Type()) {
+			return blk.NewUDiv(lhs, rhs), nil
+		}
+		return blk.NewSDiv(lhs, rhs), nil
+	case ast.Equal:
+		return blk.NewZExt(blk.NewICmp(enum.IPredEQ, lhs, rhs), types.I32), nil
+	case ast.NotEqual:
+		return blk.NewZExt(blk.NewICmp(enum.IPredNE, lhs, rhs), types.I32), nil
+	}
+	// orderings depend on the signedness
+	lt, gt, le, ge := enum.IPredSLT, enum.IPredSGT, enum.IPredSLE, enum.IPredSGE
+	if isUnsigned(lhs.Type()) {
+		lt, gt, le, ge = enum.IPredULT, enum.IPredUGT, enum.IPredULE, enum.IPredUGE
+	}
+	switch kind {
+	case ast.LessThan:
+		return blk.NewZExt(blk.NewICmp(lt, lhs, rhs), types.I32), nil
+	case ast.GreaterThan:
+		return blk.NewZExt(blk.NewICmp(gt, lhs, rhs), types.I32), nil
+	case ast.LessThanOrEqual:
+		return blk.NewZExt(blk.NewICmp(le, lhs, rhs), types.I32), nil
+	case ast.GreaterThanOrEqual:
+		return blk.NewZExt(blk.NewICmp(ge, lhs, rhs), types.I32), nil
+	}
+	return nil, errors.New("unknown operator")
+}
+
+// floatBinOp generates float operations.
+// Comparisons are ordered, except != which is true for NaN.
+func (g *Generator) floatBinOp(kind ast.BinOpKind, lhs, rhs value.Value) (value.Value, error) {
+	blk := g.blockStack.Top()
+	switch kind {
 	case ast.Add:
-		res := g.blockStack.Top().NewAdd(lhs, rhs)
-		return res, nil
+		return blk.NewFAdd(lhs, rhs), nil
 	case ast.Sub:
-		res := g.blockStack.Top().NewSub(lhs, rhs)
-		return res, nil
+		return blk.NewFSub(lhs, rhs), nil
 	case ast.Mul:
-		res := g.blockStack.Top().NewMul(lhs, rhs)
-		return res, nil
+		return blk.NewFMul(lhs, rhs), nil
 	case ast.Div:
-		res := g.blockStack.Top().NewSDiv(lhs, rhs)
-		return res, nil
+		return blk.NewFDiv(lhs, rhs), nil
 	case ast.Equal:
-		b := g.blockStack.Top().NewICmp(enum.IPredEQ, lhs, rhs)
-		res := g.blockStack.Top().NewZExt(b, types.I32)
-		return res, nil
+		return blk.NewZExt(blk.NewFCmp(enum.FPredOEQ, lhs, rhs), types.I32), nil
 	case ast.NotEqual:
-		b := g.blockStack.Top().NewICmp(enum.IPredNE, lhs, rhs)
-		res := g.blockStack.Top().NewZExt(b, types.I32)
-		return res, nil
+		return blk.NewZExt(blk.NewFCmp(enum.FPredUNE, lhs, rhs), types.I32), nil
 	case ast.LessThan:
-		b := g.blockStack.Top().NewICmp(enum.IPredSLT, lhs, rhs)
-		res := g.blockStack.Top().NewZExt(b, types.I32)
-		return res, nil
+		return blk.NewZExt(blk.NewFCmp(enum.FPredOLT, lhs, rhs), types.I32), nil
 	case ast.GreaterThan:
-		b := g.blockStack.Top().NewICmp(enum.IPredSGT, lhs, rhs)
-		res := g.blockStack.Top().NewZExt(b, types.I32)
-		return res, nil
+		return blk.NewZExt(blk.NewFCmp(enum.FPredOGT, lhs, rhs), types.I32), nil
 	case ast.LessThanOrEqual:
-		b := g.blockStack.Top().NewICmp(enum.IPredSLE, lhs, rhs)
-		res := g.blockStack.Top().NewZExt(b, types.I32)
-		return res, nil
+		return blk.NewZExt(blk.NewFCmp(enum.FPredOLE, lhs, rhs), types.I32), nil
 	case ast.GreaterThanOrEqual:
-		b := g.blockStack.Top().NewICmp(enum.IPredSGE, lhs, rhs)
-		res := g.blockStack.Top().NewZExt(b, types.I32)
-		return res, nil
+		return blk.NewZExt(blk.NewFCmp(enum.FPredOGE, lhs, rhs), types.I32), nil
 	}
 	return nil, errors.New("unknown operator")
 }
+
+// isTrue converts the number to i1, true if it is not zero.
+func (g *Generator) isTrue(v value.Value) (value.Value, error) {
+	if isUnit(v) {
+		return nil, errors.New("cannot use () value as condition")
+	}
+	blk := g.blockStack.Top()
+	switch {
+	case types.IsInt(v.Type()):
+		return blk.NewICmp(enum.IPredNE, v, zero(v.Type())), nil
+	case types.IsFloat(v.Type()):
+		return blk.NewFCmp(enum.FPredUNE, v, zero(v.Type())), nil
+	}
+	return nil, fmt.Errorf("cannot use %s value as condition", typeName(v.Type()))
+}
+
+// cast converts the value between integer and float types.
+func (g *Generator) cast(node *ast.Cast) (value.Value, error) {
+	v, err := g.expr(node.Expr.(ast.Expr))
+	if err != nil {
+		return nil, err
+	}
+	if g.terminated() {
+		return nil, nil
+	}
+	to, err := typeByName(node.Type)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", node.Pos, err)
+	}
+	if isUnit(v) {
+		return nil, fmt.Errorf("%s: cannot convert () value to %s", node.Pos, node.Type)
+	}
+	from := v.Type()
+	blk := g.blockStack.Top()
+	switch {
+	case sameType(from, to):
+		return v, nil
+	case types.IsInt(from) && types.IsInt(to):
+		fromBits, toBits := from.(*types.IntType).BitSize, to.(*types.IntType).BitSize
+		switch {
+		case fromBits > toBits:
+			return blk.NewTrunc(v, to), nil
+		case fromBits == toBits:
+			// only the signedness changes, which LLVM types don't have
+			return blk.NewBitCast(v, to), nil
+		case isUnsigned(from):
+			return blk.NewZExt(v, to), nil
+		}
+		return blk.NewSExt(v, to), nil
+	case types.IsInt(from) && types.IsFloat(to):
+		if isUnsigned(from) {
+			return blk.NewUIToFP(v, to), nil
+		}
+		return blk.NewSIToFP(v, to), nil
+	case types.IsFloat(from) && types.IsInt(to):
+		if isUnsigned(to) {
+			return blk.NewFPToUI(v, to), nil
+		}
+		return blk.NewFPToSI(v, to), nil
+	}
+	return nil, fmt.Errorf("%s: cannot convert %s value to %s", node.Pos, typeName(from), node.Type)
+}
+
+// str returns a pointer to the first byte of the string literal.
+// Each distinct literal is emitted once as a private constant byte array.
+func (g *Generator) str(s string) value.Value {
+	def, ok := g.strs[s]
+	if !ok {
+		def = g.m.NewGlobalDef(fmt.Sprintf(".str.%d", len(g.strs)), constant.NewCharArrayFromString(s))
+		def.Linkage = enum.LinkagePrivate
+		def.UnnamedAddr = enum.UnnamedAddrUnnamedAddr
+		def.Immutable = true
+		g.strs[s] = def
+	}
+	zero := constant.NewInt(types.I64, 0)
+	return constant.NewGetElementPtr(def.ContentType, def, zero, zero)
+}
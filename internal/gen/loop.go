@@ -0,0 +1,154 @@
+package gen
+
+import (
+	"fmt"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/value"
+	"github.com/lunashade/lang/internal/ast"
+	"github.com/lunashade/lang/internal/token"
+)
+
+// loop is an enclosing loop, the target of break and continue.
+type loop struct {
+	label  string    // "'name", or empty
+	cont   *ir.Block // target of continue
+	end    *ir.Block // target of break
+	valued bool      // break may have a value
+	breaks []*ir.Incoming
+}
+
+// while generates the loop as whilecond -> whilebody -> whilecond, exiting to whileend.
+func (g *Generator) while(nd *ast.While) (value.Value, error) {
+	g.blockCount++
+	count := g.blockCount
+	fn := g.funcStack.Top()
+	condBlock := fn.NewBlock(fmt.Sprintf("whilecond%d", count))
+	bodyBlock := fn.NewBlock(fmt.Sprintf("whilebody%d", count))
+	endBlock := fn.NewBlock(fmt.Sprintf("whileend%d", count))
+	g.blockStack.Pop().NewBr(condBlock)
+
+	g.blockStack.Push(condBlock)
+	condV, err := g.expr(nd.Cond.(ast.Expr))
+	if err != nil {
+		return nil, err
+	}
+	if g.terminated() {
+		// the loop is never entered nor left
+		bodyBlock.NewUnreachable()
+		endBlock.NewUnreachable()
+		return nil, nil
+	}
+	condV, err = g.isTrue(condV)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", nd.Pos, err)
+	}
+	g.blockStack.Pop().NewCondBr(condV, bodyBlock, endBlock)
+
+	g.loops.Push(&loop{label: labelName(nd.Label), cont: condBlock, end: endBlock})
+	defer g.loops.Pop()
+	g.blockStack.Push(bodyBlock)
+	if _, err := g.expr(nd.Body.(ast.Expr)); err != nil {
+		return nil, err
+	}
+	if blk := g.blockStack.Pop(); blk.Term == nil {
+		blk.NewBr(condBlock)
+	}
+	g.blockStack.Push(endBlock)
+	return nil, nil
+}
+
+// loop generates the infinite loop as loop -> loop, exiting to loopend by break.
+// The values of break are merged by a phi in loopend.
+func (g *Generator) loop(nd *ast.Loop) (value.Value, error) {
+	g.blockCount++
+	count := g.blockCount
+	fn := g.funcStack.Top()
+	bodyBlock := fn.NewBlock(fmt.Sprintf("loop%d", count))
+	endBlock := fn.NewBlock(fmt.Sprintf("loopend%d", count))
+	g.blockStack.Pop().NewBr(bodyBlock)
+
+	l := &loop{label: labelName(nd.Label), cont: bodyBlock, end: endBlock, valued: true}
+	g.loops.Push(l)
+	defer g.loops.Pop()
+	g.blockStack.Push(bodyBlock)
+	if _, err := g.expr(nd.Body.(ast.Expr)); err != nil {
+		return nil, err
+	}
+	if blk := g.blockStack.Pop(); blk.Term == nil {
+		blk.NewBr(bodyBlock)
+	}
+	g.blockStack.Push(endBlock)
+	if len(l.breaks) == 0 {
+		endBlock.NewUnreachable()
+		return nil, nil
+	}
+	if isUnit(l.breaks[0].X) {
+		return nil, nil
+	}
+	return endBlock.NewPhi(l.breaks...), nil
+}
+
+// brk jumps to the end of the loop, with the value of the loop if any.
+func (g *Generator) brk(nd *ast.Break) error {
+	l, err := g.findLoop(nd.Label, nd.Pos, "break")
+	if err != nil {
+		return err
+	}
+	var v value.Value
+	if nd.Value != nil {
+		if !l.valued {
+			return fmt.Errorf("%s: break with value in while loop", nd.Pos)
+		}
+		v, err = g.expr(nd.Value.(ast.Expr))
+		if err != nil {
+			return err
+		}
+		if g.terminated() {
+			return nil
+		}
+	}
+	if len(l.breaks) > 0 {
+		first := l.breaks[0].X
+		if isUnit(first) != isUnit(v) || !isUnit(v) && !sameType(first.Type(), v.Type()) {
+			return fmt.Errorf("%s: mismatched types %s and %s in loop breaks", nd.Pos, valueTypeName(first), valueTypeName(v))
+		}
+	}
+	blk := g.blockStack.Top()
+	l.breaks = append(l.breaks, ir.NewIncoming(v, blk))
+	blk.NewBr(l.end)
+	return nil
+}
+
+// cont jumps to the next iteration of the loop.
+func (g *Generator) cont(nd *ast.Continue) error {
+	l, err := g.findLoop(nd.Label, nd.Pos, "continue")
+	if err != nil {
+		return err
+	}
+	g.blockStack.Top().NewBr(l.cont)
+	return nil
+}
+
+// findLoop returns the innermost loop, or the loop of the label if any.
+func (g *Generator) findLoop(label *ast.Ident, pos token.Pos, stmt string) (*loop, error) {
+	if len(g.loops) == 0 {
+		return nil, fmt.Errorf("%s: %s outside loop", pos, stmt)
+	}
+	if label == nil {
+		return g.loops.Top(), nil
+	}
+	for i := len(g.loops) - 1; i >= 0; i-- {
+		if g.loops[i].label == label.Name {
+			return g.loops[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s: undefined label %s", label.Pos, label.Name)
+}
+
+func labelName(label *ast.Ident) string {
+	if label == nil {
+		return ""
+	}
+	return label.Name
+}
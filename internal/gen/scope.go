@@ -0,0 +1,47 @@
+package gen
+
+import (
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/types"
+)
+
+// scope maps variable names declared in a block to their stack slots.
+type scope map[string]*ir.InstAlloca
+
+func (g *Generator) pushScope() {
+	s := make(scope)
+	g.scopes.Push(&s)
+}
+
+func (g *Generator) popScope() {
+	g.scopes.Pop()
+}
+
+// declare binds the name to a new stack slot in the innermost scope.
+func (g *Generator) declare(name string, ty types.Type) *ir.InstAlloca {
+	slot := g.alloca(ty)
+	(*g.scopes.Top())[name] = slot
+	return slot
+}
+
+// lookup finds the slot of the name from the innermost scope outwards.
+func (g *Generator) lookup(name string) (*ir.InstAlloca, bool) {
+	for i := len(g.scopes) - 1; i >= 0; i-- {
+		if slot, ok := (*g.scopes[i])[name]; ok {
+			return slot, true
+		}
+	}
+	return nil, false
+}
+
+// alloca allocates a stack slot in the entry block of the current function,
+// after the other allocas, so that every slot is allocated once per call.
+func (g *Generator) alloca(ty types.Type) *ir.InstAlloca {
+	entry := g.funcStack.Top().Blocks[0]
+	inst := ir.NewAlloca(ty)
+	entry.Insts = append(entry.Insts, nil)
+	copy(entry.Insts[g.allocas+1:], entry.Insts[g.allocas:])
+	entry.Insts[g.allocas] = inst
+	g.allocas++
+	return inst
+}
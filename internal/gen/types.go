@@ -0,0 +1,122 @@
+package gen
+
+import (
+	"fmt"
+
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+	"github.com/lunashade/lang/internal/ast"
+)
+
+// Unsigned types are distinct instances of the LLVM types of the same width.
+// LLVM doesn't tell them apart, but the generator does by identity,
+// which instructions keep as the types of their results.
+var (
+	u8  = &types.IntType{BitSize: 8}
+	u16 = &types.IntType{BitSize: 16}
+	u32 = &types.IntType{BitSize: 32}
+	u64 = &types.IntType{BitSize: 64}
+)
+
+// intTypes maps integer type names to LLVM types.
+var intTypes = map[string]*types.IntType{
+	"i8":  types.I8,
+	"i16": types.I16,
+	"i32": types.I32,
+	"i64": types.I64,
+	"u8":  u8,
+	"u16": u16,
+	"u32": u32,
+	"u64": u64,
+}
+
+// isUnsigned reports whether t is an unsigned integer type.
+func isUnsigned(t types.Type) bool {
+	return t == u8 || t == u16 || t == u32 || t == u64
+}
+
+// sameType reports whether t and u are the same type.
+// Unlike types.Equal, integers of different signedness are different.
+func sameType(t, u types.Type) bool {
+	if types.IsInt(t) {
+		return t == u
+	}
+	return types.Equal(t, u)
+}
+
+// typeByName returns the LLVM type of the type name.
+func typeByName(name string) (types.Type, error) {
+	if ty, ok := intTypes[name]; ok {
+		return ty, nil
+	}
+	if name == "f64" {
+		return types.Double, nil
+	}
+	return nil, fmt.Errorf("unknown type %s", name)
+}
+
+// typeName returns the type name for messages.
+func typeName(t types.Type) string {
+	switch {
+	case types.Equal(t, types.Double):
+		return "f64"
+	case types.Equal(t, types.Void):
+		return "()"
+	}
+	for name, ty := range intTypes {
+		if t == ty {
+			return name
+		}
+	}
+	return t.String()
+}
+
+// isUnit reports whether v has no value, as statements and calls of unit functions.
+func isUnit(v value.Value) bool {
+	return v == nil || types.Equal(v.Type(), types.Void)
+}
+
+// valueTypeName returns the type name of the value for messages.
+func valueTypeName(v value.Value) string {
+	if isUnit(v) {
+		return "()"
+	}
+	return typeName(v.Type())
+}
+
+// zero returns the zero value of the integer or float type.
+func zero(t types.Type) constant.Constant {
+	if types.IsFloat(t) {
+		return constant.NewFloat(t.(*types.FloatType), 0)
+	}
+	return constant.NewInt(t.(*types.IntType), 0)
+}
+
+// intLit returns the constant of the integer literal, negated if neg,
+// or an error if the value doesn't fit its type.
+func intLit(nd *ast.Int, neg bool) (value.Value, error) {
+	name := nd.Suffix
+	if name == "" {
+		name = "i32"
+	}
+	ty := intTypes[name]
+	bits := ty.BitSize
+	if name[0] == 'i' {
+		bits-- // sign bit
+	}
+	v := uint64(nd.Value)
+	switch {
+	case neg && name[0] == 'u' && v != 0:
+		return nil, fmt.Errorf("%s: integer literal -%d overflows %s", nd.Pos, v, name)
+	case neg && bits < 64 && v > 1<<bits:
+		// the minimum of signed types has no positive counterpart
+		return nil, fmt.Errorf("%s: integer literal -%d overflows %s", nd.Pos, v, name)
+	case !neg && bits < 64 && v >= 1<<bits:
+		return nil, fmt.Errorf("%s: integer literal %d overflows %s", nd.Pos, v, name)
+	}
+	if neg {
+		return constant.NewInt(ty, -nd.Value), nil
+	}
+	return constant.NewInt(ty, nd.Value), nil
+}
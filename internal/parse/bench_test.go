@@ -1,20 +1,28 @@
 package parse
 
 import (
+	"context"
+	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/lunashade/lang/internal/token"
 )
 
-var code = `
-main() {
-	((((((1))))))
+// nested returns a program of an integer nested in depth parentheses.
+func nested(depth int) string {
+	return fmt.Sprintf("main() {\n\t%s1%s\n}\n", strings.Repeat("(", depth), strings.Repeat(")", depth))
 }
-`
 
 func BenchmarkParseExpr(b *testing.B) {
-	b.StartTimer()
-	Run(token.Lex(strings.NewReader(code)))
-	b.StopTimer()
+	for _, depth := range []int{6, 64, 512} {
+		code := nested(depth)
+		b.Run(fmt.Sprint(depth), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := Run(token.Lex(context.Background(), strings.NewReader(code))); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
 }
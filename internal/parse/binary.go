@@ -0,0 +1,89 @@
+package parse
+
+import (
+	"github.com/lunashade/lang/internal/ast"
+	"github.com/lunashade/lang/internal/token/kind"
+)
+
+type assoc int
+
+const (
+	leftAssoc assoc = iota
+	rightAssoc
+)
+
+// binOp is an entry of the binary operator table.
+type binOp struct {
+	kind  ast.BinOpKind
+	prec  int // higher binds tighter, starting at 1
+	assoc assoc
+}
+
+// binOps is the binary operator table.
+// A new operator needs only an entry here.
+var binOps = map[kind.Kind]binOp{
+	kind.OrOr:         {ast.LogicalOr, 1, leftAssoc},
+	kind.AndAnd:       {ast.LogicalAnd, 2, leftAssoc},
+	kind.Equal:        {ast.Equal, 3, leftAssoc},
+	kind.NotEqual:     {ast.NotEqual, 3, leftAssoc},
+	kind.LessThan:     {ast.LessThan, 3, leftAssoc},
+	kind.GreaterThan:  {ast.GreaterThan, 3, leftAssoc},
+	kind.LessEqual:    {ast.LessThanOrEqual, 3, leftAssoc},
+	kind.GreaterEqual: {ast.GreaterThanOrEqual, 3, leftAssoc},
+	kind.Plus:         {ast.Add, 4, leftAssoc},
+	kind.Minus:        {ast.Sub, 4, leftAssoc},
+	kind.Multiply:     {ast.Mul, 5, leftAssoc},
+	kind.Divide:       {ast.Div, 5, leftAssoc},
+}
+
+// withBinOps replaces the binary operator table.
+func withBinOps(ops map[kind.Kind]binOp) Option {
+	return func(p *Parser) {
+		p.ops = ops
+	}
+}
+
+// Binary parses binary operations by precedence climbing.
+// PEG: Binary <- Cast (binop Cast)*
+func (p *Parser) Binary(pos int) (int, ast.AST, error) {
+	return p.Rule("Binary", p.binary(1))(pos)
+}
+
+// binary parses operations whose operators bind at least as tight as minPrec.
+func (p *Parser) binary(minPrec int) NonTerminal {
+	return func(pos int) (int, ast.AST, error) {
+		nx, lhs, err := p.Cast(pos)
+		if err != nil {
+			return pos, nil, err
+		}
+		for {
+			nx = p.skipDocs(nx)
+			t := p.stream.Look(nx)
+			if t == nil {
+				break
+			}
+			op, ok := p.ops[t.Kind]
+			if !ok {
+				p.expect(nx, "operator")
+				break
+			}
+			if op.prec < minPrec {
+				break
+			}
+			next := op.prec + 1
+			if op.assoc == rightAssoc {
+				next = op.prec
+			}
+			rnx, rhs, err := p.binary(next)(nx + 1)
+			if err != nil {
+				// nothing but an operand can follow the operator
+				p.report()
+				pos := p.stream.Pos(nx + 1)
+				rnx, rhs = nx+1, &ast.BadExpr{From: pos, To: pos}
+			}
+			lhs = &ast.BinOp{Kind: op.kind, LHS: lhs, RHS: rhs, Pos: t.Start}
+			nx = rnx
+		}
+		return nx, lhs, nil
+	}
+}
@@ -1,69 +1,218 @@
 package parse
 
 import (
-	"errors"
-	"reflect"
-
 	"github.com/lunashade/lang/internal/ast"
+	"github.com/lunashade/lang/internal/token"
 	"github.com/lunashade/lang/internal/token/kind"
 )
 
 type NonTerminal func(int) (int, ast.AST, error)
+
+// Cache is the memo table of rules, keyed by rule name and position.
 type Cache map[Key]*Ret
 type Key struct {
-	F   uintptr
-	Pos int
+	Rule string
+	Pos  int
 }
+
+// Ret is a memoized result of a rule, including failures.
 type Ret struct {
-	Pos int
-	Nd  ast.AST
+	Pos  int
+	Nd   ast.AST
+	Err  error
+	fail failure // what the rule expected, to replay on hits
+	lr   *lrec   // set while the rule is in progress, when Ret is its seed
+}
+
+// Left recursion is supported by growing the seed,
+// as in Warth et al., "Packrat Parsers Can Support Left Recursion".
+// A rule called again at the same position while in progress fails at first.
+// The rule detecting it becomes the head of the recursion,
+// and is re-evaluated at the position while the match gets longer,
+// each time with the previous match as the result of the recursive call.
+
+// lrec is a rule in progress, linked to the rules called before it.
+type lrec struct {
+	rule string
+	head *head // head of the left recursion which the rule is involved in
+	next *lrec
+}
+
+// head is the rule growing a left recursion at a position.
+type head struct {
+	rule     string
+	involved map[string]bool // rules on the way from the head to the recursive call
+	eval     map[string]bool // involved rules to re-evaluate in this round
 }
 
-func (p *Parser) CachedCall(f NonTerminal, pos int) (int, ast.AST, error) {
-	ptr := uintptr(reflect.ValueOf(f).Pointer())
-	key := Key{F: ptr, Pos: pos}
-	if ret, ok := p.cache[key]; ok {
-		return ret.Pos, ret.Nd, nil
+// Rule memoizes f as the rule named name,
+// so that it runs at most once at each position, except while growing
+// a left recursion. Names must be unique among the rules of the parser.
+func (p *Parser) Rule(name string, f NonTerminal) NonTerminal {
+	return func(pos int) (int, ast.AST, error) {
+		key := Key{Rule: name, Pos: pos}
+		ret, ok := p.recall(key, f)
+		switch {
+		case !ok:
+			p.traceEnter(name, pos)
+			lr := &lrec{rule: name, next: p.lrStack}
+			p.lrStack = lr
+			ret = &Ret{Pos: pos, Err: errSyntax, lr: lr}
+			p.cache[key] = ret
+			p.eval(ret, pos, f)
+			p.lrStack = lr.next
+			if lr.head == nil || lr.head.rule == name {
+				ret.lr = nil
+			}
+			if lr.head != nil && lr.head.rule == name && ret.Err == nil {
+				p.grow(ret, pos, f, lr.head)
+			}
+			p.traceExit(name, pos, ret)
+		case ret.lr != nil:
+			p.setupLR(name, ret.lr)
+			p.traceHit(name, pos, ret)
+		default:
+			p.traceHit(name, pos, ret)
+		}
+		p.merge(ret.fail)
+		return ret.Pos, ret.Nd, ret.Err
 	}
+}
+
+// eval runs f at pos and stores the result to ret.
+func (p *Parser) eval(ret *Ret, pos int, f NonTerminal) {
+	outer := p.furthest
+	p.furthest = failure{}
 	nx, node, err := f(pos)
 	if err != nil {
-		return pos, nil, err
+		nx, node = pos, nil
+	}
+	ret.Pos, ret.Nd, ret.Err = nx, node, err
+	// clip so that merging into it never writes to shared arrays
+	ret.fail = p.furthest
+	ret.fail.expected = ret.fail.expected[:len(ret.fail.expected):len(ret.fail.expected)]
+	p.furthest = outer
+}
+
+// recall looks up the memo table,
+// taking care of the rules involved in a left recursion being grown.
+func (p *Parser) recall(key Key, f NonTerminal) (*Ret, bool) {
+	ret, ok := p.cache[key]
+	h := p.heads[key.Pos]
+	if h == nil {
+		return ret, ok
 	}
-	ret := Ret{
-		Pos: nx,
-		Nd:  node,
+	if !ok && h.rule != key.Rule && !h.involved[key.Rule] {
+		// other rules do not take part in growing
+		return &Ret{Pos: key.Pos, Err: errSyntax}, true
 	}
-	p.cache[key] = &ret
+	if h.eval[key.Rule] {
+		delete(h.eval, key.Rule)
+		if !ok {
+			ret = &Ret{}
+			p.cache[key] = ret
+		}
+		p.eval(ret, key.Pos, f)
+		ret.lr = nil
+		return ret, true
+	}
+	return ret, ok
+}
 
-	return nx, node, nil
+// setupLR marks the rules called since lr as involved in its left recursion.
+func (p *Parser) setupLR(rule string, lr *lrec) {
+	if lr.head == nil {
+		lr.head = &head{rule: rule, involved: make(map[string]bool), eval: make(map[string]bool)}
+	}
+	for s := p.lrStack; s != nil && s.head != lr.head; s = s.next {
+		s.head = lr.head
+		lr.head.involved[s.rule] = true
+	}
+}
+
+// grow re-evaluates the head rule at pos while the match gets longer.
+func (p *Parser) grow(ret *Ret, pos int, f NonTerminal, h *head) {
+	p.heads[pos] = h
+	for {
+		for rule := range h.involved {
+			h.eval[rule] = true
+		}
+		var next Ret
+		p.eval(&next, pos, f)
+		ret.fail.merge(next.fail)
+		if next.Err != nil || next.Pos <= ret.Pos {
+			break
+		}
+		ret.Pos, ret.Nd = next.Pos, next.Nd
+	}
+	delete(p.heads, pos)
 }
 
 func (p *Parser) Skip(kind kind.Kind) NonTerminal {
 	return func(pos int) (int, ast.AST, error) {
 		nx, t := p.consume(kind, pos)
 		if t == nil {
-			return pos, nil, errors.New("invalid token")
+			return pos, nil, errSyntax
 		}
 		return nx, nil, nil
 	}
 }
 
+// Token is Skip storing the token to v, or nil if it does not match.
+func (p *Parser) Token(v **token.Token, kind kind.Kind) NonTerminal {
+	return func(pos int) (int, ast.AST, error) {
+		nx, t := p.consume(kind, pos)
+		*v = t
+		if t == nil {
+			return pos, nil, errSyntax
+		}
+		return nx, nil, nil
+	}
+}
+
+// Bind stores the node of cand to v when it matches.
+func (p *Parser) Bind(v *ast.AST, cand NonTerminal) NonTerminal {
+	return func(pos int) (int, ast.AST, error) {
+		nx, node, err := cand(pos)
+		if err != nil {
+			return pos, nil, err
+		}
+		*v = node
+		return nx, node, nil
+	}
+}
+
 func (p *Parser) Select(cands ...NonTerminal) NonTerminal {
 	return func(pos int) (int, ast.AST, error) {
 		var nx int
 		var node ast.AST
 		var err error
 		for _, cand := range cands {
-			nx, node, err = p.CachedCall(cand, pos)
+			nx, node, err = cand(pos)
 			if err == nil {
 				return nx, node, nil
 			}
 		}
-		// TODO: wraps error
 		return pos, nil, err
 	}
 }
 
+// Label names cand in syntax errors.
+// If cand fails without reaching past pos, name is expected there
+// instead of what cand expected. Doc comments at pos don't count,
+// as consume expects tokens after them.
+func (p *Parser) Label(name string, cand NonTerminal) NonTerminal {
+	return func(pos int) (int, ast.AST, error) {
+		saved := p.furthest
+		nx, node, err := cand(pos)
+		if at := p.skipDocs(pos); err != nil && p.furthest.pos == at {
+			p.furthest = saved
+			p.expect(at, name)
+		}
+		return nx, node, err
+	}
+}
+
 func (p *Parser) Optional(cand NonTerminal) NonTerminal {
 	return func(pos int) (int, ast.AST, error) {
 		nx, node, err := cand(pos)
@@ -103,7 +252,7 @@ func (p *Parser) Repeat(m Merger, cand NonTerminal) NonTerminal {
 
 		nodes := make([]ast.AST, 0)
 		for {
-			nx, node, err = p.CachedCall(cand, nx)
+			nx, node, err = cand(nx)
 			if err != nil {
 				break
 			}
@@ -122,13 +271,13 @@ func (p *Parser) RepeatWithOptionalLast(m Merger, cand NonTerminal, last NonTerm
 
 		nodes := make([]ast.AST, 0)
 		for {
-			nx, node, err = p.CachedCall(cand, nx)
+			nx, node, err = cand(nx)
 			if err == nil {
 				nodes = append(nodes, node)
 				continue
 			}
 			// if cand fails, try last parser and break anyway
-			nx, node, err = p.CachedCall(last, nx)
+			nx, node, err = last(nx)
 			if err == nil {
 				nodes = append(nodes, node)
 			}
@@ -0,0 +1,148 @@
+package parse
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/lunashade/lang/internal/ast"
+	"github.com/lunashade/lang/internal/token"
+	"github.com/lunashade/lang/internal/token/kind"
+)
+
+func parserOf(input string) *Parser {
+	return newParser(token.NewStream(token.Lex(context.Background(), strings.NewReader(input))))
+}
+
+func TestRuleIdentity(t *testing.T) {
+	p := parserOf("()")
+	// closures of Skip share their code, but not their rule names
+	lparen := p.Rule("open", p.Skip(kind.LeftParen))
+	rparen := p.Rule("close", p.Skip(kind.RightParen))
+	if _, _, err := lparen(0); err != nil {
+		t.Fatalf("lparen: %v", err)
+	}
+	if _, _, err := rparen(0); err == nil {
+		t.Fatalf("rparen: want error, got nil")
+	}
+	if nx, _, err := rparen(1); err != nil || nx != 2 {
+		t.Fatalf("rparen: want 2, got %d, %v", nx, err)
+	}
+}
+
+func TestRuleMemo(t *testing.T) {
+	p := parserOf("1")
+	calls := 0
+	fail := p.Rule("fail", func(pos int) (int, ast.AST, error) {
+		calls++
+		p.expect(pos, "something")
+		return pos, nil, errSyntax
+	})
+	for i := 0; i < 3; i++ {
+		if _, _, err := fail(0); err == nil {
+			t.Fatalf("want error, got nil")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("want 1 call, got %d", calls)
+	}
+	// the failure is replayed for error messages on memo hits
+	p.furthest = failure{}
+	fail(0)
+	if got, want := p.syntaxError().Error(), "1:1: expected something, found integer 1"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+// ref refers to a rule defined later.
+func ref(f *NonTerminal) NonTerminal {
+	return func(pos int) (int, ast.AST, error) {
+		return (*f)(pos)
+	}
+}
+
+func binOpOf(k ast.BinOpKind) Merger {
+	return func(nodes []ast.AST) ast.AST {
+		return &ast.BinOp{Kind: k, LHS: nodes[0], RHS: nodes[2]}
+	}
+}
+
+func TestLeftRecursion(t *testing.T) {
+	one, two, three, four := &ast.Int{Value: 1}, &ast.Int{Value: 2}, &ast.Int{Value: 3}, &ast.Int{Value: 4}
+	sub := func(lhs, rhs ast.AST) ast.AST { return &ast.BinOp{Kind: ast.Sub, LHS: lhs, RHS: rhs} }
+	tests := []struct {
+		name    string
+		grammar func(p *Parser) NonTerminal
+		input   string
+		want    ast.AST
+	}{
+		{
+			// Sum <- Sum "-" int / int
+			"direct",
+			func(p *Parser) NonTerminal {
+				var sum NonTerminal
+				sum = p.Rule("Sum", p.Select(
+					p.Concat(binOpOf(ast.Sub), ref(&sum), p.Skip(kind.Minus), p.Integer),
+					p.Integer,
+				))
+				return sum
+			},
+			"1-2-3-4",
+			sub(sub(sub(one, two), three), four),
+		},
+		{
+			// X <- Y "-" int / int
+			// Y <- X
+			"indirect",
+			func(p *Parser) NonTerminal {
+				var x, y NonTerminal
+				x = p.Rule("X", p.Select(
+					p.Concat(binOpOf(ast.Sub), ref(&y), p.Skip(kind.Minus), p.Integer),
+					p.Integer,
+				))
+				y = p.Rule("Y", ref(&x))
+				return x
+			},
+			"1-2-3",
+			sub(sub(one, two), three),
+		},
+		{
+			// Sum <- Sum "-" Prod / Prod
+			// Prod <- Prod "*" int / int
+			"nested",
+			func(p *Parser) NonTerminal {
+				var sum, prod NonTerminal
+				sum = p.Rule("Sum", p.Select(
+					p.Concat(binOpOf(ast.Sub), ref(&sum), p.Skip(kind.Minus), ref(&prod)),
+					ref(&prod),
+				))
+				prod = p.Rule("Prod", p.Select(
+					p.Concat(binOpOf(ast.Mul), ref(&prod), p.Skip(kind.Multiply), p.Integer),
+					p.Integer,
+				))
+				return sum
+			},
+			"1-2*3*4-1",
+			sub(sub(one, &ast.BinOp{
+				Kind: ast.Mul,
+				LHS:  &ast.BinOp{Kind: ast.Mul, LHS: two, RHS: three},
+				RHS:  four,
+			}), one),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := parserOf(tt.input)
+			nx, got, err := tt.grammar(p)(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if eof := p.stream.Look(nx); eof.Kind != kind.Eof {
+				t.Fatalf("want end of file, got %v", eof.Kind)
+			}
+			assert.DeepEqual(t, tt.want, got, ignorePos)
+		})
+	}
+}
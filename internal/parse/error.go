@@ -0,0 +1,152 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lunashade/lang/internal/token"
+	"github.com/lunashade/lang/internal/token/kind"
+)
+
+// Error is a syntax error at the furthest position the parser reached.
+type Error struct {
+	Pos      token.Pos
+	Expected []string // what would have been accepted there, sorted
+	Found    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: expected %s, found %s", e.Pos, orList(e.Expected), e.Found)
+}
+
+// ErrorList is a list of lexical and syntax errors in source order,
+// each a *token.Error or an *Error.
+type ErrorList []error
+
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
+
+// Err returns nil if the list is empty, the list itself otherwise.
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// sort sorts the list in source order.
+// Errors at the same position keep their order.
+func (l ErrorList) sort() {
+	sort.SliceStable(l, func(i, j int) bool {
+		return errorPos(l[i]).Offset < errorPos(l[j]).Offset
+	})
+}
+
+// errorPos returns the position of the error in the list.
+func errorPos(err error) token.Pos {
+	switch e := err.(type) {
+	case *Error:
+		return e.Pos
+	case *token.Error:
+		return e.Pos
+	}
+	return token.Pos{}
+}
+
+// orList joins items as "a, b or c".
+func orList(items []string) string {
+	if len(items) == 0 {
+		return "nothing"
+	}
+	if len(items) == 1 {
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " or " + items[len(items)-1]
+}
+
+// errSyntax is returned by rules which fail.
+// The parser converts it to an *Error of the furthest failure.
+var errSyntax = errors.New("syntax error")
+
+// failure is the furthest position where a rule failed,
+// and what was expected there.
+type failure struct {
+	pos      int
+	expected []string
+}
+
+// expect records that name was expected at pos.
+func (p *Parser) expect(pos int, name string) {
+	p.furthest.expect(pos, name)
+}
+
+// merge adds the expectations of f to the furthest failure.
+func (p *Parser) merge(f failure) {
+	p.furthest.merge(f)
+}
+
+func (f *failure) expect(pos int, name string) {
+	if pos < f.pos {
+		return
+	}
+	if pos > f.pos {
+		*f = failure{pos: pos}
+	}
+	for _, e := range f.expected {
+		if e == name {
+			return
+		}
+	}
+	f.expected = append(f.expected, name)
+}
+
+func (f *failure) merge(g failure) {
+	for _, name := range g.expected {
+		f.expect(g.pos, name)
+	}
+}
+
+// syntaxError returns the error of the furthest failure.
+func (p *Parser) syntaxError() *Error {
+	expected := append([]string(nil), p.furthest.expected...)
+	sort.Strings(expected)
+	t := p.stream.Look(p.furthest.pos)
+	return &Error{
+		Pos:      t.Start,
+		Expected: expected,
+		Found:    describe(t),
+	}
+}
+
+// report records the error of the furthest failure before recovering from it,
+// and starts tracking failures afresh.
+// Rules may be tried more than once, so only the first error at a position is kept.
+func (p *Parser) report() {
+	err := p.syntaxError()
+	p.furthest = failure{}
+	for _, e := range p.errs {
+		if errorPos(e) == err.Pos {
+			return
+		}
+	}
+	p.errs = append(p.errs, err)
+	p.errs.sort()
+}
+
+// describe returns a description of the token with its text for literals.
+func describe(t *token.Token) string {
+	switch t.Kind {
+	case kind.Identifier, kind.Integer, kind.Float, kind.String, kind.Char:
+		return t.Kind.String() + " " + t.Sval
+	}
+	return t.Kind.String()
+}
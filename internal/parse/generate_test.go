@@ -0,0 +1,31 @@
+package parse
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/lunashade/lang/internal/pegen"
+)
+
+func TestGenerated(t *testing.T) {
+	src, err := os.ReadFile("grammar.peg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := pegen.Parse("grammar.peg", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := pegen.Generate(g, "parse", "grammar.peg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("peg_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("peg_gen.go is stale, run go generate ./internal/parse")
+	}
+}
@@ -7,26 +7,151 @@ import (
 )
 
 type Parser struct {
-	stream *token.Stream
-	cache  Cache
+	stream   *token.Stream
+	cache    Cache
+	furthest failure
+	errs     ErrorList
+
+	lrStack *lrec         // rules in progress, innermost first
+	heads   map[int]*head // left recursions being grown by position
+
+	ops map[kind.Kind]binOp // binary operator table
+
+	trace *tracer
 }
 
-func Run(ch chan token.Token) (ast.AST, error) {
-	p := &Parser{
-		stream: token.NewStream(ch),
-		cache:  make(Cache),
+// Run parses tokens from the channel returned by token.Lex.
+func Run(ch <-chan token.Token, opts ...Option) (ast.AST, error) {
+	return RunStream(token.NewStream(ch), opts...)
+}
+
+// RunStream parses tokens from the stream.
+// On errors, it returns the tree parsed so far with every error found.
+func RunStream(s *token.Stream, opts ...Option) (ast.AST, error) {
+	p := newParser(s)
+	for _, opt := range opts {
+		opt(p)
 	}
 	node, err := p.Root(0)
-	if err != nil {
-		return nil, err
+	if p.trace != nil {
+		p.trace.summary()
+	}
+	if lexErrs := p.stream.Errors(); len(lexErrs) > 0 {
+		return node, mergeErrors(lexErrs, p.errs)
 	}
-	return node, nil
+	return node, err
 }
 
-func (p *Parser) consume(kind kind.Kind, at int) (int, *token.Token) {
+// mergeErrors merges lexical and syntax errors in source order.
+// A syntax error at the position of a lexical error is dropped,
+// as it is found at the invalid token the lexical error left.
+func mergeErrors(lexErrs token.ErrorList, errs ErrorList) ErrorList {
+	merged := make(ErrorList, 0, len(lexErrs)+len(errs))
+	at := make(map[token.Pos]bool)
+	for _, e := range lexErrs {
+		merged = append(merged, e)
+		at[e.Pos] = true
+	}
+	for _, e := range errs {
+		if !at[errorPos(e)] {
+			merged = append(merged, e)
+		}
+	}
+	merged.sort()
+	return merged
+}
+
+func newParser(s *token.Stream) *Parser {
+	return &Parser{
+		stream: s,
+		cache:  make(Cache),
+		heads:  make(map[int]*head),
+		ops:    binOps,
+	}
+}
+
+// consume reads a token of the kind at the position.
+// If the token is of another kind, the kind is recorded as expected.
+// Doc comments are only read when asked for, and skipped elsewhere,
+// so that they are comments except before a function.
+func (p *Parser) consume(k kind.Kind, at int) (int, *token.Token) {
+	if k != kind.DocComment {
+		at = p.skipDocs(at)
+	}
 	t := p.stream.Look(at)
-	if t == nil || t.Kind != kind {
+	p.traceLook(at)
+	if t == nil || t.Kind != k {
+		p.expect(at, k.String())
 		return at, nil
 	}
 	return at + 1, t
 }
+
+// skipDocs skips doc comments at the position.
+func (p *Parser) skipDocs(at int) int {
+	for p.stream.Look(at).Kind == kind.DocComment {
+		at++
+	}
+	return at
+}
+
+// skipStmt skips tokens to the end of a broken statement:
+// after the next ";", or before the "}" closing the block.
+func (p *Parser) skipStmt(at int) int {
+	depth := 0
+	for {
+		switch p.stream.Look(at).Kind {
+		case kind.Eof:
+			return at
+		case kind.DocComment:
+			if depth == 0 {
+				return at
+			}
+		case kind.LeftBrace:
+			depth++
+		case kind.RightBrace:
+			if depth == 0 {
+				return at
+			}
+			depth--
+		case kind.Semicolon:
+			if depth == 0 {
+				return at + 1
+			}
+		}
+		at++
+	}
+}
+
+// atEnd reports whether a block cannot continue at the position,
+// which is the end of input or the doc comment of the next function.
+func (p *Parser) atEnd(at int) bool {
+	k := p.stream.Look(at).Kind
+	return k == kind.Eof || k == kind.DocComment
+}
+
+// skipFunction skips tokens of a broken function
+// to the header of the next one, that is, doc comments or ident "(".
+func (p *Parser) skipFunction(at int) int {
+	depth := 0
+	for at++; ; at++ {
+		switch t := p.stream.Look(at); t.Kind {
+		case kind.Eof:
+			return at
+		case kind.LeftBrace:
+			depth++
+		case kind.RightBrace:
+			if depth > 0 {
+				depth--
+			}
+		case kind.DocComment:
+			if depth == 0 {
+				return at
+			}
+		case kind.Identifier:
+			if depth == 0 && p.stream.Look(at+1).Kind == kind.LeftParen {
+				return at
+			}
+		}
+	}
+}
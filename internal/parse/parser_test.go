@@ -1,16 +1,23 @@
 package parse
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"gotest.tools/assert"
 
 	"github.com/lunashade/lang/internal/ast"
 	"github.com/lunashade/lang/internal/token"
+	"github.com/lunashade/lang/internal/token/kind"
 )
 
+// ignorePos ignores source positions in the tree.
+var ignorePos = cmpopts.IgnoreTypes(token.Pos{})
+
 func TestParseExpr(t *testing.T) {
 	tests := []struct {
 		input string
@@ -64,12 +71,68 @@ func TestParseExpr(t *testing.T) {
 			"1+1+1",
 			&ast.BinOp{
 				Kind: ast.Add,
-				LHS:  &ast.Int{Value: 1},
-				RHS: &ast.BinOp{
+				LHS: &ast.BinOp{
 					Kind: ast.Add,
 					LHS:  &ast.Int{Value: 1},
 					RHS:  &ast.Int{Value: 1},
 				},
+				RHS: &ast.Int{Value: 1},
+			},
+		},
+		{
+			"10-3-2",
+			&ast.BinOp{
+				Kind: ast.Sub,
+				LHS: &ast.BinOp{
+					Kind: ast.Sub,
+					LHS:  &ast.Int{Value: 10},
+					RHS:  &ast.Int{Value: 3},
+				},
+				RHS: &ast.Int{Value: 2},
+			},
+		},
+		{
+			"100/10/5",
+			&ast.BinOp{
+				Kind: ast.Div,
+				LHS: &ast.BinOp{
+					Kind: ast.Div,
+					LHS:  &ast.Int{Value: 100},
+					RHS:  &ast.Int{Value: 10},
+				},
+				RHS: &ast.Int{Value: 5},
+			},
+		},
+		{
+			"1-2*3+4",
+			&ast.BinOp{
+				Kind: ast.Add,
+				LHS: &ast.BinOp{
+					Kind: ast.Sub,
+					LHS:  &ast.Int{Value: 1},
+					RHS: &ast.BinOp{
+						Kind: ast.Mul,
+						LHS:  &ast.Int{Value: 2},
+						RHS:  &ast.Int{Value: 3},
+					},
+				},
+				RHS: &ast.Int{Value: 4},
+			},
+		},
+		{
+			"1<2==3>4",
+			&ast.BinOp{
+				Kind: ast.GreaterThan,
+				LHS: &ast.BinOp{
+					Kind: ast.Equal,
+					LHS: &ast.BinOp{
+						Kind: ast.LessThan,
+						LHS:  &ast.Int{Value: 1},
+						RHS:  &ast.Int{Value: 2},
+					},
+					RHS: &ast.Int{Value: 3},
+				},
+				RHS: &ast.Int{Value: 4},
 			},
 		},
 		{
@@ -116,6 +179,129 @@ func TestParseExpr(t *testing.T) {
 				RHS:  &ast.Int{Value: 1},
 			},
 		},
+		{
+			"1==1",
+			&ast.BinOp{
+				Kind: ast.Equal,
+				LHS:  &ast.Int{Value: 1},
+				RHS:  &ast.Int{Value: 1},
+			},
+		},
+		{
+			"1!=1",
+			&ast.BinOp{
+				Kind: ast.NotEqual,
+				LHS:  &ast.Int{Value: 1},
+				RHS:  &ast.Int{Value: 1},
+			},
+		},
+		{
+			"0xff_ffu16",
+			&ast.Int{Value: 0xffff, Suffix: "u16"},
+		},
+		{
+			"1.5*2e3",
+			&ast.BinOp{
+				Kind: ast.Mul,
+				LHS:  &ast.Float{Value: 1.5},
+				RHS:  &ast.Float{Value: 2000},
+			},
+		},
+		{
+			"1+2 as f64 as i64*3",
+			&ast.BinOp{
+				Kind: ast.Add,
+				LHS:  &ast.Int{Value: 1},
+				RHS: &ast.BinOp{
+					Kind: ast.Mul,
+					LHS: &ast.Cast{
+						Expr: &ast.Cast{Expr: &ast.Int{Value: 2}, Type: "f64"},
+						Type: "i64",
+					},
+					RHS: &ast.Int{Value: 3},
+				},
+			},
+		},
+		{
+			`'\u{3b1}'-'a'`,
+			&ast.BinOp{
+				Kind: ast.Sub,
+				LHS:  &ast.Char{Value: 'α'},
+				RHS:  &ast.Char{Value: 'a'},
+			},
+		},
+		{
+			`"a\tb"`,
+			&ast.String{Value: "a\tb"},
+		},
+		{
+			"a*(b-1)",
+			&ast.BinOp{
+				Kind: ast.Mul,
+				LHS:  &ast.Ident{Name: "a"},
+				RHS: &ast.BinOp{
+					Kind: ast.Sub,
+					LHS:  &ast.Ident{Name: "b"},
+					RHS:  &ast.Int{Value: 1},
+				},
+			},
+		},
+		{
+			"f()+g(1, h(x),)",
+			&ast.BinOp{
+				Kind: ast.Add,
+				LHS:  &ast.Call{Name: "f", Args: []ast.AST{}},
+				RHS: &ast.Call{
+					Name: "g",
+					Args: []ast.AST{
+						&ast.Int{Value: 1},
+						&ast.Call{Name: "h", Args: []ast.AST{&ast.Ident{Name: "x"}}},
+					},
+				},
+			},
+		},
+		{
+			"-1*~!x as i64",
+			&ast.BinOp{
+				Kind: ast.Mul,
+				LHS:  &ast.UnaryOp{Kind: ast.Neg, Expr: &ast.Int{Value: 1}},
+				RHS: &ast.Cast{
+					Expr: &ast.UnaryOp{
+						Kind: ast.Complement,
+						Expr: &ast.UnaryOp{Kind: ast.Not, Expr: &ast.Ident{Name: "x"}},
+					},
+					Type: "i64",
+				},
+			},
+		},
+		{
+			"1--2",
+			&ast.BinOp{
+				Kind: ast.Sub,
+				LHS:  &ast.Int{Value: 1},
+				RHS:  &ast.UnaryOp{Kind: ast.Neg, Expr: &ast.Int{Value: 2}},
+			},
+		},
+		{
+			"a||b&&c<d||e",
+			&ast.BinOp{
+				Kind: ast.LogicalOr,
+				LHS: &ast.BinOp{
+					Kind: ast.LogicalOr,
+					LHS:  &ast.Ident{Name: "a"},
+					RHS: &ast.BinOp{
+						Kind: ast.LogicalAnd,
+						LHS:  &ast.Ident{Name: "b"},
+						RHS: &ast.BinOp{
+							Kind: ast.LessThan,
+							LHS:  &ast.Ident{Name: "c"},
+							RHS:  &ast.Ident{Name: "d"},
+						},
+					},
+				},
+				RHS: &ast.Ident{Name: "e"},
+			},
+		},
 		{
 			"if 1==1 then 25 else 30",
 			&ast.IfExpr{
@@ -132,17 +318,184 @@ func TestParseExpr(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
 			input := fmt.Sprintf("main(){%s}", tt.input)
-			ch := token.Lex(strings.NewReader(input))
+			ch := token.Lex(context.Background(), strings.NewReader(input))
 			node, _ := Run(ch)
 			root := node.(*ast.Root)
 			fn := root.Nodes[0].(*ast.Function)
 			body := fn.Body[0].(*ast.ExprStmt)
 			got := body.Expr
-			assert.DeepEqual(t, tt.want, got)
+			assert.DeepEqual(t, tt.want, got, ignorePos)
 		})
 	}
 }
 
+func TestParseRightAssoc(t *testing.T) {
+	// borrow '::' as a right-associative operator
+	ops := map[kind.Kind]binOp{kind.ColonColon: {ast.Assign, 1, rightAssoc}}
+	for k, op := range binOps {
+		ops[k] = op
+	}
+
+	input := "main(){1::2::3+4}"
+	ch := token.Lex(context.Background(), strings.NewReader(input))
+	node, err := Run(ch, withBinOps(ops))
+	assert.NilError(t, err)
+	got := node.(*ast.Root).Nodes[0].(*ast.Function).Body[0].(*ast.ExprStmt).Expr
+	want := &ast.BinOp{
+		Kind: ast.Assign,
+		LHS:  &ast.Int{Value: 1},
+		RHS: &ast.BinOp{
+			Kind: ast.Assign,
+			LHS:  &ast.Int{Value: 2},
+			RHS: &ast.BinOp{
+				Kind: ast.Add,
+				LHS:  &ast.Int{Value: 3},
+				RHS:  &ast.Int{Value: 4},
+			},
+		},
+	}
+	assert.DeepEqual(t, want, got, ignorePos)
+}
+
+func TestParseSplitOperator(t *testing.T) {
+	for _, input := range []string{"1 = = 1", "1 < = 1", "1 > = 1", "1 ! = 1"} {
+		t.Run(input, func(t *testing.T) {
+			ch := token.Lex(context.Background(), strings.NewReader(fmt.Sprintf("main(){%s}", input)))
+			if _, err := Run(ch); err == nil {
+				t.Errorf("want error, got nil")
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"main(){\n  1;\n  then }", "3:3: expected 'break', 'continue', 'let', 'return', '}' or expression, found 'then'"},
+		{"main(){ 1 then }", "1:11: expected ';', 'as', '}' or operator, found 'then'"},
+		{"main(){ 1 + }", "1:13: expected expression, found '}'"},
+		{"main(){ (1 }", "1:12: expected ')', 'as' or operator, found '}'"},
+		{"main(){ 1 as 2 }", "1:14: expected identifier, found integer 2"},
+		{"main(){ let 1 = 2; 0 }", "1:13: expected identifier, found integer 1"},
+		{"f(a i32){}", "1:5: expected ':', found identifier i32"},
+		{"main(){ f(1 2) }", "1:13: expected ')', ',', 'as' or operator, found integer 2"},
+		{"main(){ return 1 }", "1:18: expected ';', 'as' or operator, found '}'"},
+		{"main(){ 'a: 1 }", "1:13: expected 'loop' or 'while', found integer 1"},
+		{"main(){ - }", "1:11: expected expression, found '}'"},
+		{"main(", "1:6: expected ')' or parameter, found end of file"},
+		{"main(){}}", "1:9: expected end of file or function, found '}'"},
+		{"/// doc\n1", "2:1: expected end of file or function, found integer 1"},
+		{"main(){ /// x\n then }", "2:2: expected 'break', 'continue', 'let', 'return', '}' or expression, found 'then'"},
+		{"main(){ 1 + /// x\n then }", "2:2: expected expression, found 'then'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			ch := token.Lex(context.Background(), strings.NewReader(tt.input))
+			_, err := Run(ch)
+			var errs ErrorList
+			if !errors.As(err, &errs) || len(errs) != 1 {
+				t.Fatalf("want one *Error, got %v", err)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("want %q, got %q", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestParseRecover(t *testing.T) {
+	input := `/// broken header
+main( {}
+f(){ 1 + ; 2 then 3; 4 }
+g(){ (5 }
+h(){ 6`
+	ch := token.Lex(context.Background(), strings.NewReader(input))
+	node, err := Run(ch)
+	var errs ErrorList
+	if !errors.As(err, &errs) {
+		t.Fatalf("want ErrorList, got %v", err)
+	}
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Error())
+	}
+	assert.DeepEqual(t, []string{
+		"2:7: expected ')' or parameter, found '{'",
+		"3:10: expected expression, found ';'",
+		"3:14: expected ';', 'as', '}' or operator, found 'then'",
+		"4:9: expected ')', 'as' or operator, found '}'",
+		"5:7: expected ';', 'as', '}' or operator, found end of file",
+	}, got)
+
+	want := &ast.Root{Nodes: []ast.AST{
+		&ast.Function{
+			Name: &ast.Ident{Name: "f"},
+			Body: []ast.AST{
+				&ast.Semi{Expr: &ast.BinOp{Kind: ast.Add, LHS: &ast.Int{Value: 1}, RHS: &ast.BadExpr{}}},
+				&ast.BadStmt{},
+				&ast.ExprStmt{Expr: &ast.Int{Value: 4}},
+			},
+		},
+		&ast.Function{
+			Name: &ast.Ident{Name: "g"},
+			Body: []ast.AST{&ast.BadStmt{}},
+		},
+		&ast.Function{
+			Name: &ast.Ident{Name: "h"},
+			Body: []ast.AST{&ast.ExprStmt{Expr: &ast.Int{Value: 6}}},
+		},
+	}}
+	assert.DeepEqual(t, want, node, ignorePos)
+}
+
+func TestParseLexicalErrors(t *testing.T) {
+	input := "main(){ 1 + ; 2 }\nf( { }\ng(){ let = 3; 4 }\nh(){ 1 # 2 }"
+	ch := token.Lex(context.Background(), strings.NewReader(input))
+	_, err := Run(ch)
+	var errs ErrorList
+	if !errors.As(err, &errs) {
+		t.Fatalf("want ErrorList, got %v", err)
+	}
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Error())
+	}
+	assert.DeepEqual(t, []string{
+		"1:13: expected expression, found ';'",
+		"2:4: expected ')' or parameter, found '{'",
+		"3:10: expected identifier, found '='",
+		"4:8: unexpected character '#'",
+	}, got)
+}
+
+func TestTrace(t *testing.T) {
+	var b strings.Builder
+	ch := token.Lex(context.Background(), strings.NewReader("main(){ (1) }"))
+	if _, err := Run(ch, Trace(&b)); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"> Function 1:1 identifier main\n",
+		"  > Block 1:7 '{'\n",
+		"        > Assign 1:9 '('\n",
+		"          > Identifier 1:9 '('\n",
+		"          < Identifier 1:9 fail\n",
+		"  < Block 1:7 ok to 1:14\n",
+		"< Function 1:1 ok to 1:14\n",
+		"--- statistics ---\n",
+		"Function   1\n",
+		"memo hits: ",
+		"max backtracking: 3 tokens\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q in trace:\n%s", want, got)
+		}
+	}
+}
+
 func TestParseFunc(t *testing.T) {
 	tests := []struct {
 		input string
@@ -168,15 +521,134 @@ func TestParseFunc(t *testing.T) {
 				},
 			},
 		},
+		{
+			"/// Doc comment\n/// of main.\nmain(){}",
+			&ast.Function{
+				Doc: "Doc comment\nof main.",
+				Name: &ast.Ident{
+					Name: "main",
+				},
+				Body: []ast.AST{},
+			},
+		},
+		{
+			"main(){let x = 1; x = x + 2; x}",
+			&ast.Function{
+				Name: &ast.Ident{
+					Name: "main",
+				},
+				Body: []ast.AST{
+					&ast.Let{
+						Name:  "x",
+						Value: &ast.Int{Value: 1},
+					},
+					&ast.Semi{
+						Expr: &ast.BinOp{
+							Kind: ast.Assign,
+							LHS:  &ast.Ident{Name: "x"},
+							RHS: &ast.BinOp{
+								Kind: ast.Add,
+								LHS:  &ast.Ident{Name: "x"},
+								RHS:  &ast.Int{Value: 2},
+							},
+						},
+					},
+					&ast.ExprStmt{
+						Expr: &ast.Ident{Name: "x"},
+					},
+				},
+			},
+		},
+		{
+			"add(a: i32, b: f64,) -> f64 { 0.0 }",
+			&ast.Function{
+				Name: &ast.Ident{Name: "add"},
+				Params: []*ast.Param{
+					{Name: "a", Type: &ast.Ident{Name: "i32"}},
+					{Name: "b", Type: &ast.Ident{Name: "f64"}},
+				},
+				Ret: &ast.Ident{Name: "f64"},
+				Body: []ast.AST{
+					&ast.ExprStmt{Expr: &ast.Float{Value: 0}},
+				},
+			},
+		},
+		{
+			"f() -> () { return; return 1 + 2; }",
+			&ast.Function{
+				Name: &ast.Ident{Name: "f"},
+				Ret:  &ast.Ident{Name: "()"},
+				Body: []ast.AST{
+					&ast.Return{},
+					&ast.Return{
+						Value: &ast.BinOp{
+							Kind: ast.Add,
+							LHS:  &ast.Int{Value: 1},
+							RHS:  &ast.Int{Value: 2},
+						},
+					},
+				},
+			},
+		},
+		{
+			"main(){ 'outer: while 1 { loop { break 'outer; continue; break 2; }; } }",
+			&ast.Function{
+				Name: &ast.Ident{Name: "main"},
+				Body: []ast.AST{
+					&ast.ExprStmt{
+						Expr: &ast.While{
+							Label: &ast.Ident{Name: "'outer"},
+							Cond:  &ast.Int{Value: 1},
+							Body: &ast.Block{
+								Stmts: []ast.AST{
+									&ast.Semi{
+										Expr: &ast.Loop{
+											Body: &ast.Block{
+												Stmts: []ast.AST{
+													&ast.Break{Label: &ast.Ident{Name: "'outer"}},
+													&ast.Continue{},
+													&ast.Break{Value: &ast.Int{Value: 2}},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			"/// f\nf(){\n/// note\n1 + /// more\n2 /// op\n* 3;\n/// last\n}\n/// trailing",
+			&ast.Function{
+				Doc:  "f",
+				Name: &ast.Ident{Name: "f"},
+				Body: []ast.AST{
+					&ast.Semi{
+						Expr: &ast.BinOp{
+							Kind: ast.Add,
+							LHS:  &ast.Int{Value: 1},
+							RHS: &ast.BinOp{
+								Kind: ast.Mul,
+								LHS:  &ast.Int{Value: 2},
+								RHS:  &ast.Int{Value: 3},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
 			input := fmt.Sprintf("%s", tt.input)
-			ch := token.Lex(strings.NewReader(input))
-			node, _ := Run(ch)
+			ch := token.Lex(context.Background(), strings.NewReader(input))
+			node, err := Run(ch)
+			assert.NilError(t, err)
 			root := node.(*ast.Root)
 			got := root.Nodes[0].(*ast.Function)
-			assert.DeepEqual(t, tt.want, got)
+			assert.DeepEqual(t, tt.want, got, ignorePos)
 		})
 	}
 
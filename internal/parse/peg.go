@@ -1,353 +1,180 @@
 package parse
 
 import (
-	"errors"
-	"strconv"
+	"strings"
 
 	"github.com/lunashade/lang/internal/ast"
+	"github.com/lunashade/lang/internal/token"
 	"github.com/lunashade/lang/internal/token/kind"
 )
 
-// === PEG ===
-// Root <- Function*
-// Function <- ident "(" ")" Block
-// --- statements ---
-// Stmt <- Stmt2 / ExprStmt
-// Stmt2 <- Semi
-// [Semi] <- Expr ";"
-// [ExprStmt] <- Expr
-// --- expressions ---
-// Expr <- Assign / Expr2
-// [Assign] <- ident "=" Expr2
-// Expr2 <- If / Cond
-// [If] <- "if" Expr "then" Expr ("else" Expr)?
-// Cond <- Eq / Neq / Lteq / Gteq / Lt / Gt / Sum
-// [Eq] <- Sum "==" Cond
-// [Neq] <- Sum "!=" Cond
-// Sum <- Add / Sub / Prod
-// [Add] <- Prod "+" Sum
-// [Sub] <- Prod "-" Sum
-// Prod <- Mul / Div / Primary
-// [Mul] <- Primary "*" Prod
-// [Div] <- Primary "/" Prod
-// Primary <- Block / ParenExpr / int / ident
-// [ParenExpr] <- "(" Expr ")"
-// [Block] <- "{" Stmt2* ExprStmt?  "}"
+//go:generate go run ../pegen/cmd/pegen -o peg_gen.go grammar.peg
 
-// Root parses root node
+// Rules written in Go. The other rules are generated from grammar.peg.
+
+// Root parses root node.
+// Broken functions are skipped to the next function header.
 // PEG: Root <- Function*
 func (p *Parser) Root(pos int) (ast.AST, error) {
-	_, node, err := p.Repeat(
-		func(nodes []ast.AST) ast.AST {
-			return &ast.Root{Nodes: nodes}
-		},
-		p.Select(p.Function),
-	)(0)
-	if err != nil {
-		return nil, err
+	root := &ast.Root{}
+	for {
+		if _, t := p.consume(kind.Eof, pos); t != nil {
+			return root, p.errs.Err()
+		}
+		nx, node, err := p.Label("function", p.Function)(pos)
+		if err != nil {
+			p.report()
+			pos = p.skipFunction(pos)
+			continue
+		}
+		root.Nodes = append(root.Nodes, node)
+		pos = nx
 	}
-	if !p.stream.Complete() {
-		return nil, errors.New("not at eof")
-	}
-	return node, nil
 }
 
 // Function parses function node
-// PEG: Function <- ident "(" ")" Block
+// PEG: Function <- doc* Signature Block
 func (p *Parser) Function(pos int) (int, ast.AST, error) {
-	return p.Concat(
-		func(nodes []ast.AST) ast.AST {
-			body := nodes[3].(*ast.Block)
-			return &ast.Function{
-				Name: nodes[0],
-				Body: body.Stmts,
-			}
-		},
-		p.Identifier,
-		p.Skip(kind.LeftParen),
-		p.Skip(kind.RightParen),
-		p.Block,
-	)(pos)
-}
-
-func (p *Parser) Block(pos int) (int, ast.AST, error) {
-	return p.Concat(
-		func(nodes []ast.AST) ast.AST {
-			return nodes[1]
-		},
-		p.Skip(kind.LeftBrace),
-		p.RepeatWithOptionalLast(
-			func(nodes []ast.AST) ast.AST {
-				return &ast.Block{
-					Stmts: nodes,
-				}
-			},
-			p.Stmt2, // no ExprStmt
-			p.ExprStmt,
-		),
-		p.Skip(kind.RightBrace),
-	)(pos)
-}
-
-func (p *Parser) Stmt(pos int) (int, ast.AST, error) {
-	return p.Select(p.Stmt2, p.ExprStmt)(pos)
-}
-func (p *Parser) Stmt2(pos int) (int, ast.AST, error) {
-	return p.Select(p.Semi)(pos)
-}
-
-func (p *Parser) ExprStmt(pos int) (int, ast.AST, error) {
-	return p.Concat(
-		func(nodes []ast.AST) ast.AST {
-			return &ast.ExprStmt{
-				Expr: nodes[0],
-			}
-		},
-		p.Expr,
-	)(pos)
-}
-
-func (p *Parser) Semi(pos int) (int, ast.AST, error) {
-	return p.Concat(
-		func(nodes []ast.AST) ast.AST {
-			return &ast.Semi{
-				Expr: nodes[0],
-			}
-		},
-		p.Expr,
-		p.Skip(kind.Semicolon),
-	)(pos)
-}
-
-func (p *Parser) Expr(pos int) (int, ast.AST, error) {
-	return p.Select(p.Assign, p.Expr2)(pos)
-}
-
-func (p *Parser) Assign(pos int) (int, ast.AST, error) {
-	return p.Concat(
-		func(nodes []ast.AST) ast.AST {
-			return &ast.BinOp{
-				Kind: ast.Assign, LHS: nodes[0], RHS: nodes[2],
-			}
-		},
-		p.Identifier,
-		p.Skip(kind.Assign),
-		p.Expr2,
-	)(pos)
-}
-
-func (p *Parser) Expr2(pos int) (int, ast.AST, error) {
-	return p.Select(p.If, p.Cond)(pos)
-}
-
-func (p *Parser) If(pos int) (int, ast.AST, error) {
-	snd := func(nodes []ast.AST) ast.AST { return nodes[1] }
-	return p.Concat(
-		func(nodes []ast.AST) ast.AST {
-			return &ast.IfExpr{
-				Cond: nodes[0],
-				Then: nodes[1],
-				Els:  nodes[2],
-			}
-		},
-		p.Concat(snd, p.Skip(kind.KwIf), p.Expr),
-		p.Concat(snd, p.Skip(kind.KwThen), p.Expr),
-		p.Optional(p.Concat(snd, p.Skip(kind.KwElse), p.Expr)),
-	)(pos)
-}
-
-func (p *Parser) Cond(pos int) (int, ast.AST, error) {
-	return p.Select(
-		p.Eq,
-		p.Neq,
-		p.Lteq,
-		p.Gteq,
-		p.Lt,
-		p.Gt,
-		p.Sum,
-	)(pos)
-}
-
-func (p *Parser) Eq(pos int) (int, ast.AST, error) {
-	return p.Concat(
-		func(nodes []ast.AST) ast.AST {
-			return &ast.BinOp{
-				Kind: ast.Equal,
-				LHS:  nodes[0],
-				RHS:  nodes[3],
-			}
-		},
-		p.Sum,
-		p.Skip(kind.Assign),
-		p.Skip(kind.Assign),
-		p.Cond,
-	)(pos)
-}
-
-func (p *Parser) Neq(pos int) (int, ast.AST, error) {
-	return p.Concat(
-		func(nodes []ast.AST) ast.AST {
-			return &ast.BinOp{
-				Kind: ast.NotEqual,
-				LHS:  nodes[0],
-				RHS:  nodes[3],
-			}
-		},
-		p.Sum,
-		p.Skip(kind.Not),
-		p.Skip(kind.Assign),
-		p.Cond,
-	)(pos)
-}
-
-func (p *Parser) Lteq(pos int) (int, ast.AST, error) {
-	return p.Concat(
-		func(nodes []ast.AST) ast.AST {
-			return &ast.BinOp{
-				Kind: ast.LessThanOrEqual,
-				LHS:  nodes[0],
-				RHS:  nodes[3],
-			}
-		},
-		p.Sum,
-		p.Skip(kind.LessThan),
-		p.Skip(kind.Assign),
-		p.Cond,
-	)(pos)
+	return p.Rule("Function", p.function)(pos)
 }
 
-func (p *Parser) Gteq(pos int) (int, ast.AST, error) {
-	return p.Concat(
+func (p *Parser) function(pos int) (int, ast.AST, error) {
+	start, doc := p.docComment(pos)
+	nx, node, err := p.Concat(
 		func(nodes []ast.AST) ast.AST {
-			return &ast.BinOp{
-				Kind: ast.GreaterThanOrEqual,
-				LHS:  nodes[0],
-				RHS:  nodes[3],
-			}
+			fn := nodes[0].(*ast.Function)
+			fn.Doc = doc
+			fn.Body = nodes[1].(*ast.Block).Stmts
+			return fn
 		},
-		p.Sum,
-		p.Skip(kind.GreaterThan),
-		p.Skip(kind.Assign),
-		p.Cond,
-	)(pos)
+		p.Signature,
+		p.Block,
+	)(start)
+	if err != nil {
+		return pos, nil, err
+	}
+	return nx, node, nil
+}
+
+// docComment consumes doc comments and returns their text joined by newlines.
+func (p *Parser) docComment(pos int) (int, string) {
+	var lines []string
+	for {
+		nx, t := p.consume(kind.DocComment, pos)
+		if t == nil {
+			break
+		}
+		line := strings.TrimPrefix(t.Sval, "///")
+		lines = append(lines, strings.TrimPrefix(line, " "))
+		pos = nx
+	}
+	return pos, strings.Join(lines, "\n")
 }
 
-func (p *Parser) Lt(pos int) (int, ast.AST, error) {
-	return p.Concat(
-		func(nodes []ast.AST) ast.AST {
-			return &ast.BinOp{
-				Kind: ast.LessThan,
-				LHS:  nodes[0],
-				RHS:  nodes[2],
-			}
-		},
-		p.Sum,
-		p.Skip(kind.LessThan),
-		p.Cond,
-	)(pos)
+// Block parses block node.
+// A broken statement becomes ast.BadStmt, skipped to the next ";" or "}".
+// PEG: Block <- "{" Stmt2* ExprStmt? "}"
+func (p *Parser) Block(pos int) (int, ast.AST, error) {
+	return p.Rule("Block", p.block)(pos)
 }
 
-func (p *Parser) Gt(pos int) (int, ast.AST, error) {
-	return p.Concat(
-		func(nodes []ast.AST) ast.AST {
-			return &ast.BinOp{
-				Kind: ast.GreaterThan,
-				LHS:  nodes[0],
-				RHS:  nodes[2],
+func (p *Parser) block(pos int) (int, ast.AST, error) {
+	nx, t := p.consume(kind.LeftBrace, pos)
+	if t == nil {
+		return pos, nil, errSyntax
+	}
+	block := &ast.Block{Stmts: make([]ast.AST, 0)}
+	for {
+		if next, t := p.consume(kind.RightBrace, nx); t != nil {
+			return next, block, nil
+		}
+		if next, stmt, err := p.Stmt2(nx); err == nil {
+			block.Stmts = append(block.Stmts, stmt)
+			nx = next
+			continue
+		}
+		next, stmt, err := p.ExprStmt(nx)
+		if err == nil {
+			if next, t := p.consume(kind.RightBrace, next); t != nil {
+				block.Stmts = append(block.Stmts, stmt)
+				return next, block, nil
 			}
-		},
-		p.Sum,
-		p.Skip(kind.GreaterThan),
-		p.Cond,
-	)(pos)
-}
-
-func (p *Parser) Sum(pos int) (int, ast.AST, error) {
-	return p.Select(p.Add, p.Sub, p.Prod)(pos)
-}
-func (p *Parser) Prod(pos int) (int, ast.AST, error) {
-	return p.Select(p.Mul, p.Div, p.Primary)(pos)
-}
-
-func (p *Parser) Add(pos int) (int, ast.AST, error) {
-	return p.Concat(
-		func(nodes []ast.AST) ast.AST {
-			return &ast.BinOp{
-				Kind: ast.Add, LHS: nodes[0], RHS: nodes[2]}
-		},
-		p.Prod,
-		p.Skip(kind.Plus),
-		p.Sum,
-	)(pos)
-}
-
-func (p *Parser) Sub(pos int) (int, ast.AST, error) {
-	return p.Concat(
-		func(nodes []ast.AST) ast.AST {
-			return &ast.BinOp{
-				Kind: ast.Sub, LHS: nodes[0], RHS: nodes[2]}
-		},
-		p.Prod,
-		p.Skip(kind.Minus),
-		p.Sum,
-	)(pos)
-}
-
-func (p *Parser) Mul(pos int) (int, ast.AST, error) {
-	return p.Concat(
-		func(nodes []ast.AST) ast.AST {
-			return &ast.BinOp{
-				Kind: ast.Mul, LHS: nodes[0], RHS: nodes[2]}
-		},
-		p.Primary,
-		p.Skip(kind.Multiply),
-		p.Prod,
-	)(pos)
+		}
+		p.report()
+		if err == nil && p.atEnd(next) {
+			// the closing brace is missing
+			block.Stmts = append(block.Stmts, stmt)
+			return next, block, nil
+		}
+		if p.atEnd(nx) {
+			return nx, block, nil
+		}
+		end := p.skipStmt(nx)
+		block.Stmts = append(block.Stmts, &ast.BadStmt{
+			From: p.stream.Look(nx).Start,
+			To:   p.stream.Look(end - 1).End,
+		})
+		nx = end
+	}
 }
 
-func (p *Parser) Div(pos int) (int, ast.AST, error) {
-	return p.Concat(
-		func(nodes []ast.AST) ast.AST {
-			return &ast.BinOp{
-				Kind: ast.Div, LHS: nodes[0], RHS: nodes[2]}
-		},
-		p.Primary,
-		p.Skip(kind.Divide),
-		p.Prod,
-	)(pos)
+// ident returns the identifier of an optional item, or nil if it is missing.
+func ident(node ast.AST) *ast.Ident {
+	id, _ := node.(*ast.Ident)
+	return id
 }
 
-func (p *Parser) Primary(pos int) (int, ast.AST, error) {
-	return p.Select(p.Block, p.ParenExpr, p.Integer)(pos)
+func (p *Parser) Integer(pos int) (int, ast.AST, error) {
+	nx, t := p.consume(kind.Integer, pos)
+	if t == nil {
+		return pos, nil, errSyntax
+	}
+	val, suffix, err := token.ParseInt(t.Sval)
+	if err != nil {
+		return pos, nil, err
+	}
+	return nx, &ast.Int{Value: int64(val), Suffix: suffix, Pos: t.Start}, nil
 }
 
-func (p *Parser) ParenExpr(pos int) (int, ast.AST, error) {
-	return p.Concat(
-		func(nodes []ast.AST) ast.AST {
-			return nodes[1]
-		},
-		p.Skip(kind.LeftParen),
-		p.Expr,
-		p.Skip(kind.RightParen),
-	)(pos)
+func (p *Parser) Float(pos int) (int, ast.AST, error) {
+	nx, t := p.consume(kind.Float, pos)
+	if t == nil {
+		return pos, nil, errSyntax
+	}
+	val, err := token.ParseFloat(t.Sval)
+	if err != nil {
+		return pos, nil, err
+	}
+	return nx, &ast.Float{Value: val}, nil
 }
 
-func (p *Parser) Integer(pos int) (int, ast.AST, error) {
-	nx, t := p.consume(kind.Integer, pos)
+func (p *Parser) String(pos int) (int, ast.AST, error) {
+	nx, t := p.consume(kind.String, pos)
 	if t == nil {
-		return pos, nil, errors.New("not an integer token")
+		return pos, nil, errSyntax
 	}
-	val, err := strconv.Atoi(t.Sval)
+	val, err := token.Unquote(t.Sval)
 	if err != nil {
 		return pos, nil, err
 	}
-	return nx, &ast.Int{Value: int64(val)}, nil
+	return nx, &ast.String{Value: val}, nil
 }
 
-func (p *Parser) Identifier(pos int) (int, ast.AST, error) {
-	nx, t := p.consume(kind.Identifier, pos)
+func (p *Parser) Char(pos int) (int, ast.AST, error) {
+	nx, t := p.consume(kind.Char, pos)
 	if t == nil {
-		return pos, nil, errors.New("not an identifier token")
+		return pos, nil, errSyntax
+	}
+	if strings.HasPrefix(t.Sval, "b") {
+		val, err := token.UnquoteByte(t.Sval)
+		if err != nil {
+			return pos, nil, err
+		}
+		return nx, &ast.Char{Value: rune(val), Byte: true}, nil
+	}
+	val, err := token.UnquoteChar(t.Sval)
+	if err != nil {
+		return pos, nil, err
 	}
-	return nx, &ast.Ident{Name: t.Sval}, nil
+	return nx, &ast.Char{Value: val}, nil
 }
@@ -0,0 +1,440 @@
+// Code generated by pegen from grammar.peg. DO NOT EDIT.
+
+package parse
+
+import (
+	"github.com/lunashade/lang/internal/ast"
+	"github.com/lunashade/lang/internal/token"
+	"github.com/lunashade/lang/internal/token/kind"
+)
+
+// Signature <- Identifier "(" (Param ",")* Param? ")" ("->" (Identifier / Unit))?
+func (p *Parser) Signature(pos int) (int, ast.AST, error) {
+	var last ast.AST
+	var name ast.AST
+	var param ast.AST
+	var params []ast.AST
+	var ret ast.AST
+	var ty ast.AST
+	return p.Rule("Signature", p.Concat(
+		func([]ast.AST) ast.AST {
+			if last != nil {
+				params = append(params, last)
+			}
+			fn := &ast.Function{Name: name}
+			for _, param := range params {
+				fn.Params = append(fn.Params, param.(*ast.Param))
+			}
+			if ret != nil {
+				fn.Ret = ret.(*ast.Ident)
+			}
+			return fn
+		},
+		p.Bind(&name, p.Identifier),
+		p.Skip(kind.LeftParen),
+		p.Repeat(
+			func(nodes []ast.AST) ast.AST {
+				params = nodes
+				return nil
+			},
+			p.Concat(
+				func([]ast.AST) ast.AST {
+					return param
+				},
+				p.Bind(&param, p.Param),
+				p.Skip(kind.Comma),
+			),
+		),
+		p.Bind(&last, p.Optional(
+			p.Param,
+		)),
+		p.Skip(kind.RightParen),
+		p.Bind(&ret, p.Optional(
+			p.Concat(
+				func([]ast.AST) ast.AST {
+					return ty
+				},
+				p.Skip(kind.Arrow),
+				p.Bind(&ty, p.Select(
+					p.Identifier,
+					p.Unit,
+				)),
+			),
+		)),
+	))(pos)
+}
+
+// Unit <- "(" ")"
+func (p *Parser) Unit(pos int) (int, ast.AST, error) {
+	var lp *token.Token
+	return p.Rule("Unit", p.Concat(
+		func([]ast.AST) ast.AST {
+			return &ast.Ident{Name: "()", Pos: lp.Start}
+		},
+		p.Token(&lp, kind.LeftParen),
+		p.Skip(kind.RightParen),
+	))(pos)
+}
+
+// Param <- ident ":" Identifier
+func (p *Parser) Param(pos int) (int, ast.AST, error) {
+	var name *token.Token
+	var ty ast.AST
+	return p.Rule("Param", p.Label("parameter", p.Concat(
+		func([]ast.AST) ast.AST {
+			return &ast.Param{Name: name.Sval, Type: ty.(*ast.Ident), Pos: name.Start}
+		},
+		p.Token(&name, kind.Identifier),
+		p.Skip(kind.Colon),
+		p.Bind(&ty, p.Identifier),
+	)))(pos)
+}
+
+// Stmt <- Stmt2 / ExprStmt
+func (p *Parser) Stmt(pos int) (int, ast.AST, error) {
+	return p.Rule("Stmt", p.Select(
+		p.Stmt2,
+		p.ExprStmt,
+	))(pos)
+}
+
+// Stmt2 <- Let / Return / Break / Continue / Semi
+func (p *Parser) Stmt2(pos int) (int, ast.AST, error) {
+	return p.Rule("Stmt2", p.Select(
+		p.Let,
+		p.Return,
+		p.Break,
+		p.Continue,
+		p.Semi,
+	))(pos)
+}
+
+// Let <- "let" ident "=" Expr ";"
+func (p *Parser) Let(pos int) (int, ast.AST, error) {
+	var name *token.Token
+	var value ast.AST
+	return p.Rule("Let", p.Concat(
+		func([]ast.AST) ast.AST {
+			return &ast.Let{Name: name.Sval, Value: value, Pos: name.Start}
+		},
+		p.Skip(kind.KwLet),
+		p.Token(&name, kind.Identifier),
+		p.Skip(kind.Assign),
+		p.Bind(&value, p.Expr),
+		p.Skip(kind.Semicolon),
+	))(pos)
+}
+
+// Return <- "return" Expr? ";"
+func (p *Parser) Return(pos int) (int, ast.AST, error) {
+	var ret *token.Token
+	var value ast.AST
+	return p.Rule("Return", p.Concat(
+		func([]ast.AST) ast.AST {
+			return &ast.Return{Value: value, Pos: ret.Start}
+		},
+		p.Token(&ret, kind.KwReturn),
+		p.Bind(&value, p.Optional(
+			p.Expr,
+		)),
+		p.Skip(kind.Semicolon),
+	))(pos)
+}
+
+// Break <- "break" LabelName? Expr? ";"
+func (p *Parser) Break(pos int) (int, ast.AST, error) {
+	var brk *token.Token
+	var lbl ast.AST
+	var value ast.AST
+	return p.Rule("Break", p.Concat(
+		func([]ast.AST) ast.AST {
+			return &ast.Break{Label: ident(lbl), Value: value, Pos: brk.Start}
+		},
+		p.Token(&brk, kind.KwBreak),
+		p.Bind(&lbl, p.Optional(
+			p.LabelName,
+		)),
+		p.Bind(&value, p.Optional(
+			p.Expr,
+		)),
+		p.Skip(kind.Semicolon),
+	))(pos)
+}
+
+// Continue <- "continue" LabelName? ";"
+func (p *Parser) Continue(pos int) (int, ast.AST, error) {
+	var cont *token.Token
+	var lbl ast.AST
+	return p.Rule("Continue", p.Concat(
+		func([]ast.AST) ast.AST {
+			return &ast.Continue{Label: ident(lbl), Pos: cont.Start}
+		},
+		p.Token(&cont, kind.KwContinue),
+		p.Bind(&lbl, p.Optional(
+			p.LabelName,
+		)),
+		p.Skip(kind.Semicolon),
+	))(pos)
+}
+
+// Semi <- Expr ";"
+func (p *Parser) Semi(pos int) (int, ast.AST, error) {
+	var e ast.AST
+	return p.Rule("Semi", p.Concat(
+		func([]ast.AST) ast.AST {
+			return &ast.Semi{Expr: e}
+		},
+		p.Bind(&e, p.Expr),
+		p.Skip(kind.Semicolon),
+	))(pos)
+}
+
+// ExprStmt <- Expr
+func (p *Parser) ExprStmt(pos int) (int, ast.AST, error) {
+	var e ast.AST
+	return p.Rule("ExprStmt", p.Concat(
+		func([]ast.AST) ast.AST {
+			return &ast.ExprStmt{Expr: e}
+		},
+		p.Bind(&e, p.Expr),
+	))(pos)
+}
+
+// Expr <- Assign / Expr2
+func (p *Parser) Expr(pos int) (int, ast.AST, error) {
+	return p.Rule("Expr", p.Label("expression", p.Select(
+		p.Assign,
+		p.Expr2,
+	)))(pos)
+}
+
+// Assign <- Identifier "=" Expr2
+func (p *Parser) Assign(pos int) (int, ast.AST, error) {
+	var eq *token.Token
+	var name ast.AST
+	var value ast.AST
+	return p.Rule("Assign", p.Concat(
+		func([]ast.AST) ast.AST {
+			return &ast.BinOp{Kind: ast.Assign, LHS: name, RHS: value, Pos: eq.Start}
+		},
+		p.Bind(&name, p.Identifier),
+		p.Token(&eq, kind.Assign),
+		p.Bind(&value, p.Expr2),
+	))(pos)
+}
+
+// Expr2 <- If / Binary
+func (p *Parser) Expr2(pos int) (int, ast.AST, error) {
+	return p.Rule("Expr2", p.Label("expression", p.Select(
+		p.If,
+		p.Binary,
+	)))(pos)
+}
+
+// If <- "if" Expr "then" Expr ("else" Expr)?
+func (p *Parser) If(pos int) (int, ast.AST, error) {
+	var cond ast.AST
+	var e ast.AST
+	var els ast.AST
+	var kw *token.Token
+	var then ast.AST
+	return p.Rule("If", p.Concat(
+		func([]ast.AST) ast.AST {
+			return &ast.IfExpr{Cond: cond, Then: then, Els: els, Pos: kw.Start}
+		},
+		p.Token(&kw, kind.KwIf),
+		p.Bind(&cond, p.Expr),
+		p.Skip(kind.KwThen),
+		p.Bind(&then, p.Expr),
+		p.Bind(&els, p.Optional(
+			p.Concat(
+				func([]ast.AST) ast.AST {
+					return e
+				},
+				p.Skip(kind.KwElse),
+				p.Bind(&e, p.Expr),
+			),
+		)),
+	))(pos)
+}
+
+// Cast <- Cast "as" ident / Unary
+func (p *Parser) Cast(pos int) (int, ast.AST, error) {
+	var as *token.Token
+	var e ast.AST
+	var ty *token.Token
+	return p.Rule("Cast", p.Select(
+		p.Concat(
+			func([]ast.AST) ast.AST {
+				return &ast.Cast{Expr: e, Type: ty.Sval, Pos: as.Start}
+			},
+			p.Bind(&e, p.Cast),
+			p.Token(&as, kind.KwAs),
+			p.Token(&ty, kind.Identifier),
+		),
+		p.Unary,
+	))(pos)
+}
+
+// Unary <- "-" Unary / "!" Unary / "~" Unary / Primary
+func (p *Parser) Unary(pos int) (int, ast.AST, error) {
+	var e ast.AST
+	var op *token.Token
+	return p.Rule("Unary", p.Label("expression", p.Select(
+		p.Concat(
+			func([]ast.AST) ast.AST {
+				return &ast.UnaryOp{Kind: ast.Neg, Expr: e, Pos: op.Start}
+			},
+			p.Token(&op, kind.Minus),
+			p.Bind(&e, p.Unary),
+		),
+		p.Concat(
+			func([]ast.AST) ast.AST {
+				return &ast.UnaryOp{Kind: ast.Not, Expr: e, Pos: op.Start}
+			},
+			p.Token(&op, kind.Not),
+			p.Bind(&e, p.Unary),
+		),
+		p.Concat(
+			func([]ast.AST) ast.AST {
+				return &ast.UnaryOp{Kind: ast.Complement, Expr: e, Pos: op.Start}
+			},
+			p.Token(&op, kind.Tilde),
+			p.Bind(&e, p.Unary),
+		),
+		p.Primary,
+	)))(pos)
+}
+
+// Primary <- Block / While / Loop / ParenExpr / Integer / Float / String / Char / Call / Identifier
+func (p *Parser) Primary(pos int) (int, ast.AST, error) {
+	return p.Rule("Primary", p.Label("expression", p.Select(
+		p.Block,
+		p.While,
+		p.Loop,
+		p.ParenExpr,
+		p.Integer,
+		p.Float,
+		p.String,
+		p.Char,
+		p.Call,
+		p.Identifier,
+	)))(pos)
+}
+
+// While <- LoopLabel? "while" Expr Block
+func (p *Parser) While(pos int) (int, ast.AST, error) {
+	var body ast.AST
+	var cond ast.AST
+	var kw *token.Token
+	var lbl ast.AST
+	return p.Rule("While", p.Concat(
+		func([]ast.AST) ast.AST {
+			return &ast.While{Label: ident(lbl), Cond: cond, Body: body, Pos: kw.Start}
+		},
+		p.Bind(&lbl, p.Optional(
+			p.LoopLabel,
+		)),
+		p.Token(&kw, kind.KwWhile),
+		p.Bind(&cond, p.Expr),
+		p.Bind(&body, p.Block),
+	))(pos)
+}
+
+// Loop <- LoopLabel? "loop" Block
+func (p *Parser) Loop(pos int) (int, ast.AST, error) {
+	var body ast.AST
+	var lbl ast.AST
+	return p.Rule("Loop", p.Concat(
+		func([]ast.AST) ast.AST {
+			return &ast.Loop{Label: ident(lbl), Body: body}
+		},
+		p.Bind(&lbl, p.Optional(
+			p.LoopLabel,
+		)),
+		p.Skip(kind.KwLoop),
+		p.Bind(&body, p.Block),
+	))(pos)
+}
+
+// LoopLabel <- LabelName ":"
+func (p *Parser) LoopLabel(pos int) (int, ast.AST, error) {
+	var l ast.AST
+	return p.Rule("LoopLabel", p.Concat(
+		func([]ast.AST) ast.AST {
+			return l
+		},
+		p.Bind(&l, p.LabelName),
+		p.Skip(kind.Colon),
+	))(pos)
+}
+
+// LabelName <- label
+func (p *Parser) LabelName(pos int) (int, ast.AST, error) {
+	var l *token.Token
+	return p.Rule("LabelName", p.Concat(
+		func([]ast.AST) ast.AST {
+			return &ast.Ident{Name: l.Sval, Pos: l.Start}
+		},
+		p.Token(&l, kind.Label),
+	))(pos)
+}
+
+// ParenExpr <- "(" Expr ")"
+func (p *Parser) ParenExpr(pos int) (int, ast.AST, error) {
+	var e ast.AST
+	return p.Rule("ParenExpr", p.Concat(
+		func([]ast.AST) ast.AST {
+			return e
+		},
+		p.Skip(kind.LeftParen),
+		p.Bind(&e, p.Expr),
+		p.Skip(kind.RightParen),
+	))(pos)
+}
+
+// Call <- ident "(" (Expr ",")* Expr? ")"
+func (p *Parser) Call(pos int) (int, ast.AST, error) {
+	var arg ast.AST
+	var args []ast.AST
+	var last ast.AST
+	var name *token.Token
+	return p.Rule("Call", p.Concat(
+		func([]ast.AST) ast.AST {
+			if last != nil {
+				args = append(args, last)
+			}
+			return &ast.Call{Name: name.Sval, Args: args, Pos: name.Start}
+		},
+		p.Token(&name, kind.Identifier),
+		p.Skip(kind.LeftParen),
+		p.Repeat(
+			func(nodes []ast.AST) ast.AST {
+				args = nodes
+				return nil
+			},
+			p.Concat(
+				func([]ast.AST) ast.AST {
+					return arg
+				},
+				p.Bind(&arg, p.Expr),
+				p.Skip(kind.Comma),
+			),
+		),
+		p.Bind(&last, p.Optional(
+			p.Expr,
+		)),
+		p.Skip(kind.RightParen),
+	))(pos)
+}
+
+// Identifier <- ident
+func (p *Parser) Identifier(pos int) (int, ast.AST, error) {
+	var id *token.Token
+	return p.Rule("Identifier", p.Concat(
+		func([]ast.AST) ast.AST {
+			return &ast.Ident{Name: id.Sval, Pos: id.Start}
+		},
+		p.Token(&id, kind.Identifier),
+	))(pos)
+}
@@ -0,0 +1,104 @@
+package parse
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Option configures the parser.
+type Option func(*Parser)
+
+// Trace writes a trace of rule calls to w, and statistics of them at the end.
+// Each call is written as "> Rule pos token" on entry and "< Rule pos result"
+// on exit, or as "= Rule pos result" when the result is memoized.
+func Trace(w io.Writer) Option {
+	return func(p *Parser) {
+		p.trace = &tracer{w: w, calls: make(map[string]int)}
+	}
+}
+
+type tracer struct {
+	w     io.Writer
+	depth int
+	reach int   // furthest token looked at by consume
+	saved []int // reach of the callers
+
+	calls        map[string]int
+	hits, misses int
+	backtrack    int // most tokens looked at by a failed rule
+}
+
+func (p *Parser) traceEnter(name string, pos int) {
+	tr := p.trace
+	if tr == nil {
+		return
+	}
+	tr.calls[name]++
+	tr.misses++
+	fmt.Fprintf(tr.w, "%s> %s %s %s\n", strings.Repeat("  ", tr.depth), name, p.stream.Pos(pos), describe(p.stream.Look(pos)))
+	tr.depth++
+	tr.saved = append(tr.saved, tr.reach)
+	tr.reach = pos
+}
+
+func (p *Parser) traceExit(name string, pos int, ret *Ret) {
+	tr := p.trace
+	if tr == nil {
+		return
+	}
+	tr.depth--
+	fmt.Fprintf(tr.w, "%s< %s %s %s\n", strings.Repeat("  ", tr.depth), name, p.stream.Pos(pos), p.result(ret))
+	if ret.Err != nil && tr.reach-pos > tr.backtrack {
+		tr.backtrack = tr.reach - pos
+	}
+	outer := tr.saved[len(tr.saved)-1]
+	tr.saved = tr.saved[:len(tr.saved)-1]
+	if outer > tr.reach {
+		tr.reach = outer
+	}
+}
+
+func (p *Parser) traceHit(name string, pos int, ret *Ret) {
+	tr := p.trace
+	if tr == nil {
+		return
+	}
+	tr.calls[name]++
+	tr.hits++
+	fmt.Fprintf(tr.w, "%s= %s %s %s\n", strings.Repeat("  ", tr.depth), name, p.stream.Pos(pos), p.result(ret))
+}
+
+// traceLook records that the token at the position was looked at.
+func (p *Parser) traceLook(at int) {
+	if p.trace != nil && at > p.trace.reach {
+		p.trace.reach = at
+	}
+}
+
+func (p *Parser) result(ret *Ret) string {
+	if ret.Err != nil {
+		return "fail"
+	}
+	return "ok to " + p.stream.Pos(ret.Pos).String()
+}
+
+// summary writes the statistics.
+func (tr *tracer) summary() {
+	names := make([]string, 0, len(tr.calls))
+	for name := range tr.calls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(tr.w, "--- statistics ---")
+	w := tabwriter.NewWriter(tr.w, 0, 8, 1, ' ', 0)
+	fmt.Fprintln(w, "rule\tcalls")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%d\n", name, tr.calls[name])
+	}
+	w.Flush()
+	fmt.Fprintf(tr.w, "memo hits: %d, misses: %d\n", tr.hits, tr.misses)
+	fmt.Fprintf(tr.w, "max backtracking: %d tokens\n", tr.backtrack)
+}
@@ -0,0 +1,47 @@
+// Command pegen generates parser rules from a PEG grammar file.
+//
+//	pegen [-pkg name] [-o output] grammar.peg
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lunashade/lang/internal/pegen"
+)
+
+func main() {
+	pkg := flag.String("pkg", "parse", "package name of the generated code")
+	out := flag.String("o", "", "output file (default stdout)")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: pegen [-pkg name] [-o output] grammar.peg")
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), *pkg, *out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(file, pkg, out string) error {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	g, err := pegen.Parse(filepath.Base(file), src)
+	if err != nil {
+		return err
+	}
+	code, err := pegen.Generate(g, pkg, filepath.Base(file))
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(out, code, 0o644)
+}
@@ -0,0 +1,179 @@
+package pegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Generate generates Go source of the rules as methods of Parser in package pkg.
+// source is the name of the grammar file mentioned in the header.
+func Generate(g *Grammar, pkg, source string) ([]byte, error) {
+	gen := &generator{g: g, kinds: make(map[string]string), lits: make(map[string]string)}
+	for _, t := range g.Tokens {
+		if t.Literal {
+			gen.lits[t.Name] = t.Kind
+		} else {
+			gen.kinds[t.Name] = t.Kind
+		}
+	}
+	var body bytes.Buffer
+	for _, r := range g.Rules {
+		if err := gen.rule(&body, r); err != nil {
+			return nil, err
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by pegen from %s. DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	b.WriteString("import (\n")
+	b.WriteString("\"github.com/lunashade/lang/internal/ast\"\n")
+	if gen.usesToken {
+		b.WriteString("\"github.com/lunashade/lang/internal/token\"\n")
+	}
+	if gen.usesKind {
+		b.WriteString("\"github.com/lunashade/lang/internal/token/kind\"\n")
+	}
+	b.WriteString(")\n")
+	b.Write(body.Bytes())
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return src, nil
+}
+
+type generator struct {
+	g     *Grammar
+	kinds map[string]string // token names to kinds
+	lits  map[string]string // token literals to kinds
+
+	labels    map[string]string // labels of the current rule to their types
+	usesToken bool
+	usesKind  bool
+}
+
+func (gen *generator) rule(w *bytes.Buffer, r *Rule) error {
+	gen.labels = make(map[string]string)
+	if err := gen.collect(r.Expr); err != nil {
+		return fmt.Errorf("%s: %w", r.Pos, err)
+	}
+	expr := gen.expr(r.Expr)
+	if r.Display != "" {
+		expr = fmt.Sprintf("p.Label(%s, %s)", strconv.Quote(r.Display), expr)
+	}
+	fmt.Fprintf(w, "\n// %s\n", r)
+	fmt.Fprintf(w, "func (p *Parser) %s(pos int) (int, ast.AST, error) {\n", r.Name)
+	labels := make([]string, 0, len(gen.labels))
+	for l := range gen.labels {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	for _, l := range labels {
+		fmt.Fprintf(w, "var %s %s\n", l, gen.labels[l])
+	}
+	fmt.Fprintf(w, "return p.Rule(%s, %s)(pos)\n}\n", strconv.Quote(r.Name), expr)
+	return nil
+}
+
+// collect collects the labels in e with their types.
+func (gen *generator) collect(e Expr) error {
+	switch e := e.(type) {
+	case *Choice:
+		for _, alt := range e.Alts {
+			if err := gen.collect(alt); err != nil {
+				return err
+			}
+		}
+	case *Seq:
+		for _, item := range e.Items {
+			if err := gen.collect(item); err != nil {
+				return err
+			}
+		}
+	case *Labeled:
+		ty := "ast.AST"
+		switch inner := e.Expr.(type) {
+		case *Star:
+			ty = "[]ast.AST"
+		case *Lit:
+			ty = "*token.Token"
+		case *Ref:
+			if _, ok := gen.kinds[inner.Name]; ok {
+				ty = "*token.Token"
+			}
+		}
+		if prev, ok := gen.labels[e.Label]; ok && prev != ty {
+			return fmt.Errorf("label %s is used for both %s and %s", e.Label, prev, ty)
+		}
+		gen.labels[e.Label] = ty
+		if ty == "*token.Token" {
+			gen.usesToken = true
+		}
+		return gen.collect(e.Expr)
+	case *Optional:
+		return gen.collect(e.Expr)
+	case *Star:
+		return gen.collect(e.Expr)
+	}
+	return nil
+}
+
+// expr returns Go code of the NonTerminal parsing e.
+func (gen *generator) expr(e Expr) string {
+	switch e := e.(type) {
+	case *Choice:
+		if len(e.Alts) == 1 {
+			return gen.expr(e.Alts[0])
+		}
+		return gen.call("p.Select", e.Alts)
+	case *Seq:
+		if e.Action == "" {
+			return gen.expr(e.Items[0])
+		}
+		merger := fmt.Sprintf("func([]ast.AST) ast.AST {\n%s\n}", e.Action)
+		return gen.call("p.Concat", e.Items, merger)
+	case *Labeled:
+		switch inner := e.Expr.(type) {
+		case *Star:
+			merger := fmt.Sprintf("func(nodes []ast.AST) ast.AST {\n%s = nodes\nreturn nil\n}", e.Label)
+			return gen.call("p.Repeat", []Expr{inner.Expr}, merger)
+		case *Lit:
+			return fmt.Sprintf("p.Token(&%s, %s)", e.Label, gen.kind(gen.lits[inner.Text]))
+		case *Ref:
+			if k, ok := gen.kinds[inner.Name]; ok {
+				return fmt.Sprintf("p.Token(&%s, %s)", e.Label, gen.kind(k))
+			}
+		}
+		return fmt.Sprintf("p.Bind(&%s, %s)", e.Label, gen.expr(e.Expr))
+	case *Optional:
+		return gen.call("p.Optional", []Expr{e.Expr})
+	case *Star:
+		return gen.call("p.Repeat", []Expr{e.Expr}, "func([]ast.AST) ast.AST { return nil }")
+	case *Ref:
+		if k, ok := gen.kinds[e.Name]; ok {
+			return fmt.Sprintf("p.Skip(%s)", gen.kind(k))
+		}
+		return "p." + e.Name
+	case *Lit:
+		return fmt.Sprintf("p.Skip(%s)", gen.kind(gen.lits[e.Text]))
+	}
+	panic(fmt.Sprintf("unknown expression %T", e))
+}
+
+func (gen *generator) kind(k string) string {
+	gen.usesKind = true
+	return "kind." + k
+}
+
+// call returns Go code calling the combinator with args followed by es.
+func (gen *generator) call(f string, es []Expr, args ...string) string {
+	for _, e := range es {
+		args = append(args, gen.expr(e))
+	}
+	return fmt.Sprintf("%s(\n%s,\n)", f, strings.Join(args, ",\n"))
+}
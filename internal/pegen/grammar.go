@@ -0,0 +1,150 @@
+// Package pegen generates parser rules from a PEG grammar.
+//
+// A grammar file consists of declarations and rules.
+// "#" starts a comment to the end of the line.
+//
+//	%token "(" LeftParen     # "(" in rules matches kind.LeftParen
+//	%token ident Identifier  # so does ident for kind.Identifier
+//	%extern Block Integer    # rules written in Go
+//
+//	Rule "display name" <- expression
+//
+// Expressions are ordered choices "e1 / e2" of sequences "e1 e2",
+// whose items may be labeled as "name:e", repeated as "e*",
+// made optional as "e?" or grouped as "(e)".
+// A sequence of more than one item needs an action "{ Go code }"
+// returning the ast.AST of the sequence.
+// Labels are variables in actions: ast.AST for rules and groups,
+// *token.Token for tokens, and []ast.AST for repetitions.
+package pegen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Grammar is a parsed grammar file.
+type Grammar struct {
+	Tokens  []*Token
+	Externs []string
+	Rules   []*Rule
+}
+
+// Token maps a token name used in rules to its kind.
+type Token struct {
+	Name    string // literal text or identifier
+	Literal bool   // Name is quoted in rules
+	Kind    string
+	Pos     Pos
+}
+
+// Rule is a rule to generate.
+type Rule struct {
+	Name    string
+	Display string // name in syntax errors, if any
+	Expr    Expr
+	Pos     Pos
+}
+
+func (r *Rule) String() string {
+	return fmt.Sprintf("%s <- %s", r.Name, r.Expr)
+}
+
+// Pos is a position in the grammar file.
+type Pos struct {
+	File      string
+	Line, Col int
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Col)
+}
+
+// Expr is an expression of a rule.
+// String returns it in PEG without labels and actions.
+type Expr interface {
+	String() string
+}
+
+type Choice struct {
+	Alts []Expr
+}
+
+type Seq struct {
+	Items  []Expr
+	Action string // Go code, without braces
+	Pos    Pos
+}
+
+type Labeled struct {
+	Label string
+	Expr  Expr
+}
+
+type Optional struct {
+	Expr Expr
+}
+
+type Star struct {
+	Expr Expr
+}
+
+// Ref refers to a rule or a named token.
+type Ref struct {
+	Name string
+	Pos  Pos
+}
+
+// Lit refers to a token by its literal text.
+type Lit struct {
+	Text string
+	Pos  Pos
+}
+
+func (e *Choice) String() string {
+	alts := make([]string, len(e.Alts))
+	for i, alt := range e.Alts {
+		alts[i] = alt.String()
+	}
+	return strings.Join(alts, " / ")
+}
+
+func (e *Seq) String() string {
+	items := make([]string, len(e.Items))
+	for i, item := range e.Items {
+		if l, ok := item.(*Labeled); ok {
+			item = l.Expr
+		}
+		if _, ok := item.(*Choice); ok {
+			items[i] = group(item)
+			continue
+		}
+		items[i] = item.String()
+	}
+	return strings.Join(items, " ")
+}
+
+func (e *Labeled) String() string  { return e.Expr.String() }
+func (e *Optional) String() string { return group(e.Expr) + "?" }
+func (e *Star) String() string     { return group(e.Expr) + "*" }
+func (e *Ref) String() string      { return e.Name }
+func (e *Lit) String() string      { return fmt.Sprintf("%q", e.Text) }
+
+// group parenthesizes e as an operand of a suffix.
+func group(e Expr) string {
+	switch e := e.(type) {
+	case *Ref, *Lit:
+		return e.String()
+	case *Labeled:
+		return group(e.Expr)
+	case *Seq:
+		if len(e.Items) == 1 {
+			return group(e.Items[0])
+		}
+	case *Choice:
+		if len(e.Alts) == 1 {
+			return group(e.Alts[0])
+		}
+	}
+	return "(" + e.String() + ")"
+}
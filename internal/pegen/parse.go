@@ -0,0 +1,465 @@
+package pegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tEOF       tokenKind = iota
+	tIdent               // Rule
+	tString              // "text"
+	tDirective           // %token
+	tAction              // { code }
+	tArrow               // <-
+	tSlash               // /
+	tColon               // :
+	tQuestion            // ?
+	tStar                // *
+	tLParen              // (
+	tRParen              // )
+)
+
+type token struct {
+	kind tokenKind
+	text string // identifier, unquoted string, directive name or action code
+	pos  Pos
+}
+
+// scanner splits a grammar file into tokens.
+type scanner struct {
+	src  string
+	off  int
+	line int
+	col  int
+	file string
+}
+
+func (s *scanner) errorf(pos Pos, format string, args ...any) error {
+	return fmt.Errorf("%s: %s", pos, fmt.Sprintf(format, args...))
+}
+
+func (s *scanner) pos() Pos {
+	return Pos{File: s.file, Line: s.line, Col: s.col}
+}
+
+// advance moves over n bytes of the source.
+func (s *scanner) advance(n int) {
+	for _, c := range s.src[s.off : s.off+n] {
+		if c == '\n' {
+			s.line++
+			s.col = 1
+		} else {
+			s.col++
+		}
+	}
+	s.off += n
+}
+
+func (s *scanner) scan() (token, error) {
+	// skip spaces and comments
+	for s.off < len(s.src) {
+		c := s.src[s.off]
+		if c == '#' {
+			for s.off < len(s.src) && s.src[s.off] != '\n' {
+				s.advance(1)
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+			break
+		}
+		s.advance(1)
+	}
+	pos := s.pos()
+	if s.off == len(s.src) {
+		return token{kind: tEOF, pos: pos}, nil
+	}
+	rest := s.src[s.off:]
+	switch c := rest[0]; {
+	case strings.HasPrefix(rest, "<-"):
+		s.advance(2)
+		return token{kind: tArrow, pos: pos}, nil
+	case c == '"':
+		lit, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return token{}, s.errorf(pos, "unterminated string")
+		}
+		text, _ := strconv.Unquote(lit)
+		s.advance(len(lit))
+		return token{kind: tString, text: text, pos: pos}, nil
+	case c == '{':
+		n, err := actionLen(rest)
+		if err != nil {
+			return token{}, s.errorf(pos, "%v", err)
+		}
+		s.advance(n)
+		return token{kind: tAction, text: strings.TrimSpace(rest[1 : n-1]), pos: pos}, nil
+	case c == '%':
+		n := 1 + identLen(rest[1:])
+		s.advance(n)
+		return token{kind: tDirective, text: rest[1:n], pos: pos}, nil
+	case identLen(rest) > 0:
+		n := identLen(rest)
+		s.advance(n)
+		return token{kind: tIdent, text: rest[:n], pos: pos}, nil
+	}
+	puncts := map[byte]tokenKind{'/': tSlash, ':': tColon, '?': tQuestion, '*': tStar, '(': tLParen, ')': tRParen}
+	if k, ok := puncts[rest[0]]; ok {
+		s.advance(1)
+		return token{kind: k, pos: pos}, nil
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return token{}, s.errorf(pos, "unexpected character %q", r)
+}
+
+func identLen(s string) int {
+	n := 0
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		if !(r == '_' || unicode.IsLetter(r) || n > 0 && unicode.IsDigit(r)) {
+			break
+		}
+		n += size
+	}
+	return n
+}
+
+// actionLen returns the length of the Go code in balanced braces at the start of s,
+// skipping braces in literals and comments.
+func actionLen(s string) (int, error) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		case '"', '\'', '`':
+			j := i + 1
+			for j < len(s) && s[j] != c && s[j] != '\n' {
+				if s[j] == '\\' && c != '`' {
+					j++
+				}
+				j++
+			}
+			i = j
+		case '/':
+			if strings.HasPrefix(s[i:], "//") {
+				for i < len(s) && s[i] != '\n' {
+					i++
+				}
+			}
+		}
+	}
+	return 0, fmt.Errorf("unterminated action")
+}
+
+// parser is a recursive descent parser of grammar files.
+type parser struct {
+	s    *scanner
+	toks []token // lookahead
+}
+
+// Parse parses the grammar file named file.
+func Parse(file string, src []byte) (*Grammar, error) {
+	p := &parser{s: &scanner{src: string(src), line: 1, col: 1, file: file}}
+	g := &Grammar{}
+	for {
+		t, err := p.peek(0)
+		if err != nil {
+			return nil, err
+		}
+		switch t.kind {
+		case tEOF:
+			return g, check(g)
+		case tDirective:
+			if err := p.directive(g); err != nil {
+				return nil, err
+			}
+		default:
+			r, err := p.rule()
+			if err != nil {
+				return nil, err
+			}
+			g.Rules = append(g.Rules, r)
+		}
+	}
+}
+
+func (p *parser) peek(n int) (token, error) {
+	for len(p.toks) <= n {
+		t, err := p.s.scan()
+		if err != nil {
+			return token{}, err
+		}
+		p.toks = append(p.toks, t)
+	}
+	return p.toks[n], nil
+}
+
+func (p *parser) next() (token, error) {
+	t, err := p.peek(0)
+	if err != nil {
+		return t, err
+	}
+	p.toks = p.toks[1:]
+	return t, nil
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	t, err := p.next()
+	if err != nil {
+		return t, err
+	}
+	if t.kind != kind {
+		return t, p.s.errorf(t.pos, "expected %s", what)
+	}
+	return t, nil
+}
+
+// directive parses "%token name Kind" or "%extern Rule...".
+func (p *parser) directive(g *Grammar) error {
+	d, _ := p.next()
+	switch d.text {
+	case "token":
+		name, err := p.next()
+		if err != nil {
+			return err
+		}
+		if name.kind != tString && name.kind != tIdent {
+			return p.s.errorf(name.pos, "expected token name")
+		}
+		kind, err := p.expect(tIdent, "token kind")
+		if err != nil {
+			return err
+		}
+		g.Tokens = append(g.Tokens, &Token{
+			Name:    name.text,
+			Literal: name.kind == tString,
+			Kind:    kind.text,
+			Pos:     name.pos,
+		})
+		return nil
+	case "extern":
+		for {
+			t, err := p.peek(0)
+			if err != nil {
+				return err
+			}
+			if t.kind != tIdent || p.startsRule() {
+				return nil
+			}
+			p.next()
+			g.Externs = append(g.Externs, t.text)
+		}
+	}
+	return p.s.errorf(d.pos, "unknown directive %%%s", d.text)
+}
+
+// startsRule reports whether a rule starts at the next token.
+func (p *parser) startsRule() bool {
+	t, err := p.peek(0)
+	if err != nil || t.kind != tIdent {
+		return false
+	}
+	t, err = p.peek(1)
+	if err == nil && t.kind == tString {
+		t, err = p.peek(2)
+	}
+	return err == nil && t.kind == tArrow
+}
+
+// rule parses `Name "display"? <- Choice`.
+func (p *parser) rule() (*Rule, error) {
+	name, err := p.expect(tIdent, "rule name")
+	if err != nil {
+		return nil, err
+	}
+	r := &Rule{Name: name.text, Pos: name.pos}
+	if t, _ := p.peek(0); t.kind == tString {
+		p.next()
+		r.Display = t.text
+	}
+	if _, err := p.expect(tArrow, "<-"); err != nil {
+		return nil, err
+	}
+	r.Expr, err = p.choice()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (p *parser) choice() (Expr, error) {
+	c := &Choice{}
+	for {
+		seq, err := p.seq()
+		if err != nil {
+			return nil, err
+		}
+		c.Alts = append(c.Alts, seq)
+		if t, _ := p.peek(0); t.kind != tSlash {
+			return c, nil
+		}
+		p.next()
+	}
+}
+
+func (p *parser) seq() (Expr, error) {
+	t, err := p.peek(0)
+	if err != nil {
+		return nil, err
+	}
+	s := &Seq{Pos: t.pos}
+	for {
+		t, err := p.peek(0)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case t.kind == tAction:
+			p.next()
+			s.Action = t.text
+			return s, nil
+		case t.kind == tIdent && !p.startsRule(), t.kind == tString, t.kind == tLParen:
+			item, err := p.item()
+			if err != nil {
+				return nil, err
+			}
+			s.Items = append(s.Items, item)
+		default:
+			if len(s.Items) == 0 {
+				return nil, p.s.errorf(t.pos, "expected expression")
+			}
+			return s, nil
+		}
+	}
+}
+
+// item parses `(label ":")? primary ("?" / "*")?`.
+func (p *parser) item() (Expr, error) {
+	label := ""
+	if t, _ := p.peek(1); t.kind == tColon && p.toks[0].kind == tIdent {
+		l, _ := p.next()
+		p.next()
+		label = l.text
+	}
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	var e Expr
+	switch t.kind {
+	case tIdent:
+		e = &Ref{Name: t.text, Pos: t.pos}
+	case tString:
+		e = &Lit{Text: t.text, Pos: t.pos}
+	case tLParen:
+		e, err = p.choice()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tRParen, "')'"); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, p.s.errorf(t.pos, "expected expression")
+	}
+	switch t, _ := p.peek(0); t.kind {
+	case tQuestion:
+		p.next()
+		e = &Optional{Expr: e}
+	case tStar:
+		p.next()
+		e = &Star{Expr: e}
+	}
+	if label != "" {
+		e = &Labeled{Label: label, Expr: e}
+	}
+	return e, nil
+}
+
+// check checks that names are defined and sequences have actions where needed.
+func check(g *Grammar) error {
+	names := make(map[string]string)
+	define := func(name, what string, pos Pos) error {
+		if prev, ok := names[name]; ok {
+			return fmt.Errorf("%s: %s %s is already defined as %s", pos, what, name, prev)
+		}
+		names[name] = what
+		return nil
+	}
+	lits := make(map[string]bool)
+	for _, t := range g.Tokens {
+		if t.Literal {
+			if lits[t.Name] {
+				return fmt.Errorf("%s: token %q is already defined", t.Pos, t.Name)
+			}
+			lits[t.Name] = true
+			continue
+		}
+		if err := define(t.Name, "token", t.Pos); err != nil {
+			return err
+		}
+	}
+	for _, name := range g.Externs {
+		if err := define(name, "rule", Pos{}); err != nil {
+			return err
+		}
+	}
+	for _, r := range g.Rules {
+		if err := define(r.Name, "rule", r.Pos); err != nil {
+			return err
+		}
+	}
+	var walk func(e Expr) error
+	walk = func(e Expr) error {
+		switch e := e.(type) {
+		case *Choice:
+			for _, alt := range e.Alts {
+				if err := walk(alt); err != nil {
+					return err
+				}
+			}
+		case *Seq:
+			if len(e.Items) > 1 && e.Action == "" {
+				return fmt.Errorf("%s: sequence of %d items needs an action", e.Pos, len(e.Items))
+			}
+			for _, item := range e.Items {
+				if err := walk(item); err != nil {
+					return err
+				}
+			}
+		case *Labeled:
+			return walk(e.Expr)
+		case *Optional:
+			return walk(e.Expr)
+		case *Star:
+			return walk(e.Expr)
+		case *Ref:
+			if _, ok := names[e.Name]; !ok {
+				return fmt.Errorf("%s: undefined rule or token %s", e.Pos, e.Name)
+			}
+		case *Lit:
+			if !lits[e.Text] {
+				return fmt.Errorf("%s: undefined token %q", e.Pos, e.Text)
+			}
+		}
+		return nil
+	}
+	for _, r := range g.Rules {
+		if err := walk(r.Expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package pegen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `
+%token "+" Plus
+%token int Integer
+%extern Atom
+
+# comment
+Sum "sum" <- l:Sum "+" r:Atom { return add(l, r) } / Atom
+List <- xs:(x:int { return lit(x) })* ("+" a:Atom { return a })? { return list(xs) }
+`
+	g, err := Parse("test.peg", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range g.Rules {
+		got = append(got, r.String())
+	}
+	want := []string{
+		`Sum <- Sum "+" Atom / Atom`,
+		`List <- int* ("+" Atom)?`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if g.Rules[0].Display != "sum" {
+		t.Errorf("want display name sum, got %q", g.Rules[0].Display)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`A <- B`, "test.peg:1:6: undefined rule or token B"},
+		{`A <- "x"`, `test.peg:1:6: undefined token "x"`},
+		{"%token x X\nA <- x x", "test.peg:2:6: sequence of 2 items needs an action"},
+		{"%extern A\nA <- A", "test.peg:2:1: rule A is already defined as rule"},
+		{`A <- { return nil`, "test.peg:1:6: unterminated action"},
+		{`A <- `, "test.peg:1:6: expected expression"},
+		{`%rule A`, "test.peg:1:1: unknown directive %rule"},
+	}
+	for _, tt := range tests {
+		_, err := Parse("test.peg", []byte(tt.src))
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%q: want %q, got %v", tt.src, tt.want, err)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	src := `
+%token "as" KwAs
+%token ident Identifier
+%extern Primary
+Cast <- e:Cast as:"as" ty:ident { return cast(e, as, ty) } / Primary
+`
+	g, err := Parse("test.peg", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := Generate(g, "parse", "test.peg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"// Code generated by pegen from test.peg. DO NOT EDIT.",
+		"var as *token.Token",
+		"var e ast.AST",
+		`return p.Rule("Cast", p.Select(`,
+		"p.Bind(&e, p.Cast),",
+		"p.Token(&as, kind.KwAs),",
+		"p.Token(&ty, kind.Identifier),",
+		"p.Primary,",
+	} {
+		if !strings.Contains(string(code), want) {
+			t.Errorf("want %q in:\n%s", want, code)
+		}
+	}
+}
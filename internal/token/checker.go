@@ -1,17 +1,80 @@
 package token
 
-import "github.com/lunashade/lang/internal/token/kind"
+import (
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/lunashade/lang/internal/token/kind"
+)
 
 func isDigit(c rune) bool {
 	return '0' <= c && c <= '9'
 }
 
-// check if rune of identifier
-func isIdent(c rune) bool {
-	islower := 'a' <= c && c <= 'z'
-	isupper := 'A' <= c && c <= 'Z'
-	return islower || isupper
+// check if rune of decimal digits with separators
+func isDecimal(c rune) bool {
+	return isDigit(c) || c == '_'
+}
+
+// check if rune can start identifier: '_' or XID_Start
+func isIdentStart(c rune) bool {
+	if c < utf8.RuneSelf {
+		return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_'
+	}
+	return isIDStart(c) && !unicode.Is(notXIDStart, c)
+}
+
+// check if rune can continue identifier: '_', digits or XID_Continue
+func isIdentContinue(c rune) bool {
+	if c < utf8.RuneSelf {
+		return isIdentStart(c) || isDigit(c)
+	}
+	return isIDContinue(c) && !unicode.Is(notXIDContinue, c)
+}
+
+// isIDStart reports ID_Start as derived in Unicode Standard Annex #31.
+func isIDStart(c rune) bool {
+	if unicode.In(c, unicode.Pattern_Syntax, unicode.Pattern_White_Space) {
+		return false
+	}
+	return unicode.In(c, unicode.Letter, unicode.Nl, unicode.Other_ID_Start)
+}
+
+// isIDContinue reports ID_Continue as derived in Unicode Standard Annex #31.
+func isIDContinue(c rune) bool {
+	if isIDStart(c) {
+		return true
+	}
+	if unicode.In(c, unicode.Pattern_Syntax, unicode.Pattern_White_Space) {
+		return false
+	}
+	return unicode.In(c, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue)
+}
+
+// notXIDStart is ID_Start characters excluded from XID_Start,
+// which are not closed under NFKC normalization.
+var notXIDStart = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x037a, 0x037a, 1},
+		{0x0e33, 0x0e33, 1},
+		{0x0eb3, 0x0eb3, 1},
+		{0x309b, 0x309c, 1},
+		{0xfc5e, 0xfc63, 1},
+		{0xfdfa, 0xfdfb, 1},
+		{0xfe70, 0xfe7e, 2},
+		{0xff9e, 0xff9f, 1},
+	},
+}
+
+// notXIDContinue is ID_Continue characters excluded from XID_Continue.
+var notXIDContinue = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x037a, 0x037a, 1},
+		{0x309b, 0x309c, 1},
+		{0xfc5e, 0xfc63, 1},
+		{0xfdfa, 0xfdfb, 1},
+		{0xfe70, 0xfe7e, 2},
+	},
 }
 
 func isSymbol(c rune) bool {
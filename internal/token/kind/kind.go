@@ -1,5 +1,7 @@
 package kind
 
+import "strconv"
+
 // Kind is token's kind
 type Kind int
 
@@ -7,13 +9,24 @@ const (
 	Invalid Kind = iota
 	Eof
 	Identifier
+	DocComment // "/// ..."
+	Label      // "'name"
 	// Keywords
-	KwIf   // "if"
-	KwThen // "then"
-	KwElse // "else"
+	KwIf       // "if"
+	KwThen     // "then"
+	KwElse     // "else"
+	KwAs       // "as"
+	KwLet      // "let"
+	KwReturn   // "return"
+	KwWhile    // "while"
+	KwLoop     // "loop"
+	KwBreak    // "break"
+	KwContinue // "continue"
 	// Literal
 	Integer
+	Float
 	String
+	Char
 	// Symbol
 	Plus        // '+'
 	Minus       // '-'
@@ -28,9 +41,27 @@ const (
 	GreaterThan // '>'
 	Semicolon   // ';'
 	Not         // '!'
+	Ampersand   // '&'
+	Pipe        // '|'
+	Colon       // ':'
+	Comma       // ','
+	Tilde       // '~'
+	// Operator
+	Equal          // "=="
+	NotEqual       // "!="
+	LessEqual      // "<="
+	GreaterEqual   // ">="
+	AndAnd         // "&&"
+	OrOr           // "||"
+	Arrow          // "->"
+	ColonColon     // "::"
+	PlusAssign     // "+="
+	MinusAssign    // "-="
+	MultiplyAssign // "*="
+	DivideAssign   // "/="
 )
 
-const Symbols = "+-*/=(){}<>;!"
+const Symbols = "+-*/=(){}<>;!&|:,~"
 
 func SymbolKind(c rune) Kind {
 	for i, r := range Symbols {
@@ -41,8 +72,24 @@ func SymbolKind(c rune) Kind {
 	return Invalid
 }
 
+// Operators are punctuations with multiple characters
+var Operators = []string{
+	"==", "!=", "<=", ">=", "&&", "||", "->", "::",
+	"+=", "-=", "*=", "/=",
+}
+
+func OperatorKind(s string) Kind {
+	for i, op := range Operators {
+		if op == s {
+			return Kind(i + int(Equal))
+		}
+	}
+	return Invalid
+}
+
 var Keywords = []string{
-	"if", "then", "else",
+	"if", "then", "else", "as", "let", "return",
+	"while", "loop", "break", "continue",
 }
 
 func KeywordKind(s string) Kind {
@@ -53,3 +100,31 @@ func KeywordKind(s string) Kind {
 	}
 	return Identifier
 }
+
+var names = [...]string{
+	Invalid:    "invalid token",
+	Eof:        "end of file",
+	Identifier: "identifier",
+	DocComment: "doc comment",
+	Label:      "label",
+	Integer:    "integer",
+	Float:      "float",
+	String:     "string",
+	Char:       "character",
+}
+
+// String returns the quoted text of keywords and punctuations,
+// or a description of the other kinds.
+func (k Kind) String() string {
+	switch {
+	case KwIf <= k && k < KwIf+Kind(len(Keywords)):
+		return "'" + Keywords[k-KwIf] + "'"
+	case Plus <= k && k < Plus+Kind(len(Symbols)):
+		return "'" + Symbols[k-Plus:k-Plus+1] + "'"
+	case Equal <= k && k < Equal+Kind(len(Operators)):
+		return "'" + Operators[k-Equal] + "'"
+	case 0 <= k && int(k) < len(names) && names[k] != "":
+		return names[k]
+	}
+	return "kind(" + strconv.Itoa(int(k)) + ")"
+}
@@ -2,8 +2,12 @@ package token
 
 import (
 	"bufio"
+	"context"
 	"errors"
+	"fmt"
 	"io"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/lunashade/lang/internal/token/kind"
 )
@@ -14,37 +18,136 @@ type lexer struct {
 	src    *bufio.Reader
 	peeked rune
 	buf    []rune
-	ch     chan Token
+	out    []Token // emitted tokens not yet read
+	err    error   // I/O error, reported once at the end of input
+
+	rawRead bool // the last next read an invalid UTF-8 byte
+
+	trivia  bool     // keep trivia on tokens
+	pending []string // trivia pieces since the last token
+	held    *Token   // the last token, waiting for its trailing trivia
+
+	pos   Pos // position of the next rune
+	prev  Pos // position before the last next, restored by backup
+	start Pos // start position of buf
+}
+
+// Option configures the lexer.
+type Option func(*lexer)
+
+// WithFile sets the file name recorded in token positions.
+func WithFile(name string) Option {
+	return func(l *lexer) {
+		l.pos.File = name
+	}
 }
 
-func Lex(r io.Reader) chan Token {
+func newLexer(r io.Reader, opts ...Option) *lexer {
 	l := &lexer{
 		src: bufio.NewReader(r),
 		buf: make([]rune, 0, 8),
-		ch:  make(chan Token),
+		pos: Pos{Offset: 0, Line: 1, Col: 1},
 	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.prev = l.pos
+	l.start = l.pos
+	return l
+}
+
+// WithTrivia keeps whitespace and comments on tokens as
+// Leading and Trailing trivia, so that tokens reproduce the input.
+func WithTrivia() Option {
+	return func(l *lexer) {
+		l.trivia = true
+	}
+}
+
+// Lex starts lexing in a goroutine and sends tokens to the returned channel.
+// The channel is closed after the Eof token, or when ctx is cancelled.
+func Lex(ctx context.Context, r io.Reader, opts ...Option) <-chan Token {
+	s := NewScanner(r, opts...)
+	ch := make(chan Token)
+	go func() {
+		defer close(ch)
+		for {
+			tok := s.Next()
+			select {
+			case ch <- tok:
+			case <-ctx.Done():
+				return
+			}
+			if tok.Kind == kind.Eof {
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// Scanner is a lexer without goroutine, which reads tokens on demand.
+type Scanner struct {
+	l     *lexer
+	state stateFn
+	last  Token
+}
 
-	go l.run()
-	return l.ch
+func NewScanner(r io.Reader, opts ...Option) *Scanner {
+	return &Scanner{l: newLexer(r, opts...), state: lexSkip}
 }
 
-func (l *lexer) run() {
-	for state := lexSkip; state != nil; {
-		state = state(l)
+// Next returns the next token. After the end of input, it returns Eof forever.
+func (s *Scanner) Next() Token {
+	for len(s.l.out) == 0 {
+		if s.state == nil {
+			return s.last
+		}
+		s.state = s.state(s.l)
 	}
-	close(l.ch)
+	s.last = s.l.out[0]
+	s.l.out = s.l.out[1:]
+	return s.last
+}
+
+// rawByte is added to an invalid UTF-8 byte to keep it in buf as a rune.
+// The result is in the surrogate range, so it never clashes with a valid rune.
+const rawByte rune = 0xdc00
+
+func isRawByte(c rune) bool {
+	return rawByte+0x80 <= c && c <= rawByte+0xff
 }
 
 func (l *lexer) next() rune {
-	c, _, err := l.src.ReadRune()
+	c, size, err := l.src.ReadRune()
+	l.rawRead = false
+	if err == nil && c == utf8.RuneError && size == 1 {
+		// read the invalid byte again to keep it
+		l.src.UnreadRune()
+		b, _ := l.src.ReadByte()
+		c = rawByte + rune(b)
+		l.rawRead = true
+	}
 	l.peeked = c
 	if err != nil {
 		if errors.Is(err, io.EOF) {
 			l.peeked = eof
+			l.prev = l.pos
 			return eof
 		}
-		// TODO: handle err
-		panic(err)
+		// stop reading and report it as an invalid token
+		l.err = err
+		l.peeked = eof
+		l.prev = l.pos
+		return eof
+	}
+	l.prev = l.pos
+	l.pos.Offset += size
+	if c == '\n' {
+		l.pos.Line++
+		l.pos.Col = 1
+	} else {
+		l.pos.Col += size
 	}
 	return c
 }
@@ -52,10 +155,16 @@ func (l *lexer) backup() {
 	if l.peeked == eof {
 		return
 	}
-	if err := l.src.UnreadRune(); err != nil {
-		// TODO: handle err
-		panic(err)
+	unread := l.src.UnreadRune
+	if l.rawRead {
+		unread = l.src.UnreadByte
 	}
+	if err := unread(); err != nil {
+		l.err = err
+		l.peeked = eof
+		return
+	}
+	l.pos = l.prev
 }
 
 func (l *lexer) peek() rune {
@@ -64,8 +173,92 @@ func (l *lexer) peek() rune {
 	return c
 }
 
+// peekAt returns the byte n bytes ahead without consuming it,
+// or 0 at the end of input. It must not be followed by backup.
+func (l *lexer) peekAt(n int) byte {
+	b, _ := l.src.Peek(n + 1)
+	if len(b) <= n {
+		return 0
+	}
+	return b[n]
+}
+
+// acceptRun consumes runes while valid.
+func (l *lexer) acceptRun(valid func(rune) bool) {
+	for {
+		c := l.next()
+		if !valid(c) {
+			break
+		}
+		l.buf = append(l.buf, c)
+	}
+	l.backup()
+}
+
+// text returns the source text of buf.
+func (l *lexer) text() string {
+	var b strings.Builder
+	for _, c := range l.buf {
+		if isRawByte(c) {
+			b.WriteByte(byte(c - rawByte))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// ignore drops the runes consumed since the last token.
+// In trivia mode, they are kept as a trivia piece.
+func (l *lexer) ignore() {
+	if l.trivia {
+		l.pending = append(l.pending, l.text())
+	}
+	l.buf = l.buf[:0]
+	l.start = l.pos
+}
+
 func (l *lexer) emit(kind kind.Kind) {
-	tok := makeToken(kind, string(l.buf))
-	l.ch <- tok
+	tok := makeToken(kind, l.text(), l.start, l.pos)
+	l.push(tok)
+	l.buf = nil
+	l.start = l.pos
+}
+
+// errorf emits an invalid token of the runes consumed so far.
+func (l *lexer) errorf(format string, args ...any) {
+	tok := makeToken(kind.Invalid, l.text(), l.start, l.pos)
+	tok.Err = &Error{Pos: l.start, Msg: fmt.Sprintf(format, args...)}
+	l.push(tok)
 	l.buf = nil
+	l.start = l.pos
+}
+
+// push queues the token.
+// In trivia mode, a token is held until its trailing trivia is known:
+// the pending pieces up to the next newline trail the held token,
+// and the rest lead the new one.
+func (l *lexer) push(tok Token) {
+	if !l.trivia {
+		l.out = append(l.out, tok)
+		return
+	}
+	rest := l.pending
+	if l.held != nil {
+		i := 0
+		for i < len(rest) && rest[i] != "\n" {
+			i++
+		}
+		l.held.Trailing = strings.Join(rest[:i], "")
+		rest = rest[i:]
+		l.out = append(l.out, *l.held)
+		l.held = nil
+	}
+	tok.Leading = strings.Join(rest, "")
+	l.pending = nil
+	if tok.Kind == kind.Eof {
+		l.out = append(l.out, tok)
+		return
+	}
+	l.held = &tok
 }
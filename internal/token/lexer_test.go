@@ -1,125 +1,301 @@
 package token
 
 import (
+	"context"
+	"errors"
+	"io"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/lunashade/lang/internal/token/kind"
 )
 
-func TestLex(t *testing.T) {
-	tests := []struct {
-		name  string
-		input string
-		want  []Token
-	}{
-		// number
-		{
-			"number", "1",
-			[]Token{
-				{Kind: kind.Integer, Sval: "1"},
-				{Kind: kind.Eof, Sval: ""},
-			},
+// lexTests are inputs and tokens shared by the lexer tests.
+var lexTests = []struct {
+	name  string
+	input string
+	want  []Token
+}{
+	// number
+	{
+		"number", "1",
+		[]Token{
+			{Kind: kind.Integer, Sval: "1"},
+			{Kind: kind.Eof, Sval: ""},
 		},
-		{
-			"number with skip", "255\n\n",
-			[]Token{
-				{Kind: kind.Integer, Sval: "255"},
-				{Kind: kind.Eof, Sval: ""},
-			},
+	},
+	{
+		"number with skip", "255\n\n",
+		[]Token{
+			{Kind: kind.Integer, Sval: "255"},
+			{Kind: kind.Eof, Sval: ""},
 		},
-		{
-			"numbers", "255\t\n78\n361\n",
-			[]Token{
-				{Kind: kind.Integer, Sval: "255"},
-				{Kind: kind.Integer, Sval: "78"},
-				{Kind: kind.Integer, Sval: "361"},
-				{Kind: kind.Eof, Sval: ""},
-			},
+	},
+	{
+		"numbers", "255\t\n78\n361\n",
+		[]Token{
+			{Kind: kind.Integer, Sval: "255"},
+			{Kind: kind.Integer, Sval: "78"},
+			{Kind: kind.Integer, Sval: "361"},
+			{Kind: kind.Eof, Sval: ""},
 		},
-		// punct
-		{
-			"symbol", "+-*/=(){}<>;!",
-			[]Token{
-				{Kind: kind.Plus, Sval: "+"},
-				{Kind: kind.Minus, Sval: "-"},
-				{Kind: kind.Multiply, Sval: "*"},
-				{Kind: kind.Divide, Sval: "/"},
-				{Kind: kind.Assign, Sval: "="},
-				{Kind: kind.LeftParen, Sval: "("},
-				{Kind: kind.RightParen, Sval: ")"},
-				{Kind: kind.LeftBrace, Sval: "{"},
-				{Kind: kind.RightBrace, Sval: "}"},
-				{Kind: kind.LessThan, Sval: "<"},
-				{Kind: kind.GreaterThan, Sval: ">"},
-				{Kind: kind.Semicolon, Sval: ";"},
-				{Kind: kind.Not, Sval: "!"},
-				{Kind: kind.Eof, Sval: ""},
-			},
+	},
+	{
+		"prefixed numbers", "0xff 0b1010 0o17 0 007 1_000_000 255u8 1i64 0xffu16",
+		[]Token{
+			{Kind: kind.Integer, Sval: "0xff"},
+			{Kind: kind.Integer, Sval: "0b1010"},
+			{Kind: kind.Integer, Sval: "0o17"},
+			{Kind: kind.Integer, Sval: "0"},
+			{Kind: kind.Integer, Sval: "007"},
+			{Kind: kind.Integer, Sval: "1_000_000"},
+			{Kind: kind.Integer, Sval: "255u8"},
+			{Kind: kind.Integer, Sval: "1i64"},
+			{Kind: kind.Integer, Sval: "0xffu16"},
+			{Kind: kind.Eof, Sval: ""},
 		},
-		{
-			"symbol with numbers", "255 + 78* 361",
-			[]Token{
-				{Kind: kind.Integer, Sval: "255"},
-				{Kind: kind.Plus, Sval: "+"},
-				{Kind: kind.Integer, Sval: "78"},
-				{Kind: kind.Multiply, Sval: "*"},
-				{Kind: kind.Integer, Sval: "361"},
-				{Kind: kind.Eof, Sval: ""},
-			},
+	},
+	{
+		"floats", "1.5 2e-3 1_000.25E+2 7f64 3.0f64 1.e 1.x",
+		[]Token{
+			{Kind: kind.Float, Sval: "1.5"},
+			{Kind: kind.Float, Sval: "2e-3"},
+			{Kind: kind.Float, Sval: "1_000.25E+2"},
+			{Kind: kind.Float, Sval: "7f64"},
+			{Kind: kind.Float, Sval: "3.0f64"},
+			{Kind: kind.Integer, Sval: "1"},
+			{Kind: kind.Invalid, Sval: "."},
+			{Kind: kind.Identifier, Sval: "e"},
+			{Kind: kind.Integer, Sval: "1"},
+			{Kind: kind.Invalid, Sval: "."},
+			{Kind: kind.Identifier, Sval: "x"},
+			{Kind: kind.Eof, Sval: ""},
 		},
-		{
-			"paren", "1 + (2 * 3)",
-			[]Token{
-				{Kind: kind.Integer, Sval: "1"},
-				{Kind: kind.Plus, Sval: "+"},
-				{Kind: kind.LeftParen, Sval: "("},
-				{Kind: kind.Integer, Sval: "2"},
-				{Kind: kind.Multiply, Sval: "*"},
-				{Kind: kind.Integer, Sval: "3"},
-				{Kind: kind.RightParen, Sval: ")"},
-				{Kind: kind.Eof, Sval: ""},
-			},
+	},
+	// punct
+	{
+		"symbol", "+-*/ =(){}<>;!",
+		[]Token{
+			{Kind: kind.Plus, Sval: "+"},
+			{Kind: kind.Minus, Sval: "-"},
+			{Kind: kind.Multiply, Sval: "*"},
+			{Kind: kind.Divide, Sval: "/"},
+			{Kind: kind.Assign, Sval: "="},
+			{Kind: kind.LeftParen, Sval: "("},
+			{Kind: kind.RightParen, Sval: ")"},
+			{Kind: kind.LeftBrace, Sval: "{"},
+			{Kind: kind.RightBrace, Sval: "}"},
+			{Kind: kind.LessThan, Sval: "<"},
+			{Kind: kind.GreaterThan, Sval: ">"},
+			{Kind: kind.Semicolon, Sval: ";"},
+			{Kind: kind.Not, Sval: "!"},
+			{Kind: kind.Eof, Sval: ""},
 		},
-		{
-			"identifier", "abc efg",
-			[]Token{
-				{Kind: kind.Identifier, Sval: "abc"},
-				{Kind: kind.Identifier, Sval: "efg"},
-				{Kind: kind.Eof, Sval: ""},
-			},
+	},
+	{
+		"operators", "== != <= >= && || -> :: += -= *= /=",
+		[]Token{
+			{Kind: kind.Equal, Sval: "=="},
+			{Kind: kind.NotEqual, Sval: "!="},
+			{Kind: kind.LessEqual, Sval: "<="},
+			{Kind: kind.GreaterEqual, Sval: ">="},
+			{Kind: kind.AndAnd, Sval: "&&"},
+			{Kind: kind.OrOr, Sval: "||"},
+			{Kind: kind.Arrow, Sval: "->"},
+			{Kind: kind.ColonColon, Sval: "::"},
+			{Kind: kind.PlusAssign, Sval: "+="},
+			{Kind: kind.MinusAssign, Sval: "-="},
+			{Kind: kind.MultiplyAssign, Sval: "*="},
+			{Kind: kind.DivideAssign, Sval: "/="},
+			{Kind: kind.Eof, Sval: ""},
 		},
-		{
-			"simple function", "main(){1+1} ",
-			[]Token{
-				{Kind: kind.Identifier, Sval: "main"},
-				{Kind: kind.LeftParen, Sval: "("},
-				{Kind: kind.RightParen, Sval: ")"},
-				{Kind: kind.LeftBrace, Sval: "{"},
-				{Kind: kind.Integer, Sval: "1"},
-				{Kind: kind.Plus, Sval: "+"},
-				{Kind: kind.Integer, Sval: "1"},
-				{Kind: kind.RightBrace, Sval: "}"},
-				{Kind: kind.Eof, Sval: ""},
-			},
+	},
+	{
+		"maximal munch", "<=== = = =&|:,~",
+		[]Token{
+			{Kind: kind.LessEqual, Sval: "<="},
+			{Kind: kind.Equal, Sval: "=="},
+			{Kind: kind.Assign, Sval: "="},
+			{Kind: kind.Assign, Sval: "="},
+			{Kind: kind.Assign, Sval: "="},
+			{Kind: kind.Ampersand, Sval: "&"},
+			{Kind: kind.Pipe, Sval: "|"},
+			{Kind: kind.Colon, Sval: ":"},
+			{Kind: kind.Comma, Sval: ","},
+			{Kind: kind.Tilde, Sval: "~"},
+			{Kind: kind.Eof, Sval: ""},
 		},
-		{
-			"keywords", "if then else ifs",
-			[]Token{
-				{Kind: kind.KwIf, Sval: "if"},
-				{Kind: kind.KwThen, Sval: "then"},
-				{Kind: kind.KwElse, Sval: "else"},
-				{Kind: kind.Identifier, Sval: "ifs"},
-				{Kind: kind.Eof, Sval: ""},
-			},
+	},
+	{
+		"symbol with numbers", "255 + 78* 361",
+		[]Token{
+			{Kind: kind.Integer, Sval: "255"},
+			{Kind: kind.Plus, Sval: "+"},
+			{Kind: kind.Integer, Sval: "78"},
+			{Kind: kind.Multiply, Sval: "*"},
+			{Kind: kind.Integer, Sval: "361"},
+			{Kind: kind.Eof, Sval: ""},
 		},
-	}
+	},
+	{
+		"paren", "1 + (2 * 3)",
+		[]Token{
+			{Kind: kind.Integer, Sval: "1"},
+			{Kind: kind.Plus, Sval: "+"},
+			{Kind: kind.LeftParen, Sval: "("},
+			{Kind: kind.Integer, Sval: "2"},
+			{Kind: kind.Multiply, Sval: "*"},
+			{Kind: kind.Integer, Sval: "3"},
+			{Kind: kind.RightParen, Sval: ")"},
+			{Kind: kind.Eof, Sval: ""},
+		},
+	},
+	{
+		"identifier", "abc efg",
+		[]Token{
+			{Kind: kind.Identifier, Sval: "abc"},
+			{Kind: kind.Identifier, Sval: "efg"},
+			{Kind: kind.Eof, Sval: ""},
+		},
+	},
+	{
+		"unicode identifier", "x1 snake_case _ _x café 변수 e\u0301 a\u0663 ifs2 if2",
+		[]Token{
+			{Kind: kind.Identifier, Sval: "x1"},
+			{Kind: kind.Identifier, Sval: "snake_case"},
+			{Kind: kind.Identifier, Sval: "_"},
+			{Kind: kind.Identifier, Sval: "_x"},
+			{Kind: kind.Identifier, Sval: "café"},
+			{Kind: kind.Identifier, Sval: "변수"},
+			{Kind: kind.Identifier, Sval: "e\u0301"},
+			{Kind: kind.Identifier, Sval: "a\u0663"},
+			{Kind: kind.Identifier, Sval: "ifs2"},
+			{Kind: kind.Identifier, Sval: "if2"},
+			{Kind: kind.Eof, Sval: ""},
+		},
+	},
+	{
+		"not identifier", "\u0301a \u0663 ゛",
+		[]Token{
+			{Kind: kind.Invalid, Sval: "\u0301"},
+			{Kind: kind.Identifier, Sval: "a"},
+			{Kind: kind.Invalid, Sval: "\u0663"},
+			{Kind: kind.Invalid, Sval: "゛"},
+			{Kind: kind.Eof, Sval: ""},
+		},
+	},
+	{
+		"simple function", "main(){1+1} ",
+		[]Token{
+			{Kind: kind.Identifier, Sval: "main"},
+			{Kind: kind.LeftParen, Sval: "("},
+			{Kind: kind.RightParen, Sval: ")"},
+			{Kind: kind.LeftBrace, Sval: "{"},
+			{Kind: kind.Integer, Sval: "1"},
+			{Kind: kind.Plus, Sval: "+"},
+			{Kind: kind.Integer, Sval: "1"},
+			{Kind: kind.RightBrace, Sval: "}"},
+			{Kind: kind.Eof, Sval: ""},
+		},
+	},
+	{
+		"line comment", "1 // 2 + 3\n4 //",
+		[]Token{
+			{Kind: kind.Integer, Sval: "1"},
+			{Kind: kind.Integer, Sval: "4"},
+			{Kind: kind.Eof, Sval: ""},
+		},
+	},
+	{
+		"block comment", "1 /* 2 /* nested */ 3 */ / 4 /**/",
+		[]Token{
+			{Kind: kind.Integer, Sval: "1"},
+			{Kind: kind.Divide, Sval: "/"},
+			{Kind: kind.Integer, Sval: "4"},
+			{Kind: kind.Eof, Sval: ""},
+		},
+	},
+	{
+		"doc comment", "/// doc\n//// not doc\nmain",
+		[]Token{
+			{Kind: kind.DocComment, Sval: "/// doc"},
+			{Kind: kind.Identifier, Sval: "main"},
+			{Kind: kind.Eof, Sval: ""},
+		},
+	},
+	{
+		"string", `"" "a b" "\n\t\\\"\0\u{3b1}" "//"`,
+		[]Token{
+			{Kind: kind.String, Sval: `""`},
+			{Kind: kind.String, Sval: `"a b"`},
+			{Kind: kind.String, Sval: `"\n\t\\\"\0\u{3b1}"`},
+			{Kind: kind.String, Sval: `"//"`},
+			{Kind: kind.Eof, Sval: ""},
+		},
+	},
+	{
+		"char", `'a' '\n' '\'' '"' '\u{3b1}' 'é'`,
+		[]Token{
+			{Kind: kind.Char, Sval: `'a'`},
+			{Kind: kind.Char, Sval: `'\n'`},
+			{Kind: kind.Char, Sval: `'\''`},
+			{Kind: kind.Char, Sval: `'"'`},
+			{Kind: kind.Char, Sval: `'\u{3b1}'`},
+			{Kind: kind.Char, Sval: `'é'`},
+			{Kind: kind.Eof, Sval: ""},
+		},
+	},
+	{
+		"byte", `b'a' b'\n' b'\u{ff}' b 'c' ab'c'`,
+		[]Token{
+			{Kind: kind.Char, Sval: `b'a'`},
+			{Kind: kind.Char, Sval: `b'\n'`},
+			{Kind: kind.Char, Sval: `b'\u{ff}'`},
+			{Kind: kind.Identifier, Sval: "b"},
+			{Kind: kind.Char, Sval: `'c'`},
+			{Kind: kind.Identifier, Sval: "ab"},
+			{Kind: kind.Char, Sval: `'c'`},
+			{Kind: kind.Eof, Sval: ""},
+		},
+	},
+	{
+		"label", `'outer: 'a 'b' '_x1`,
+		[]Token{
+			{Kind: kind.Label, Sval: `'outer`},
+			{Kind: kind.Colon, Sval: ":"},
+			{Kind: kind.Label, Sval: `'a`},
+			{Kind: kind.Char, Sval: `'b'`},
+			{Kind: kind.Label, Sval: `'_x1`},
+			{Kind: kind.Eof, Sval: ""},
+		},
+	},
+	{
+		"keywords", "if then else as let return while loop break continue ifs",
+		[]Token{
+			{Kind: kind.KwIf, Sval: "if"},
+			{Kind: kind.KwThen, Sval: "then"},
+			{Kind: kind.KwElse, Sval: "else"},
+			{Kind: kind.KwAs, Sval: "as"},
+			{Kind: kind.KwLet, Sval: "let"},
+			{Kind: kind.KwReturn, Sval: "return"},
+			{Kind: kind.KwWhile, Sval: "while"},
+			{Kind: kind.KwLoop, Sval: "loop"},
+			{Kind: kind.KwBreak, Sval: "break"},
+			{Kind: kind.KwContinue, Sval: "continue"},
+			{Kind: kind.Identifier, Sval: "ifs"},
+			{Kind: kind.Eof, Sval: ""},
+		},
+	},
+}
 
-	for _, tt := range tests {
+func TestLex(t *testing.T) {
+	for _, tt := range lexTests {
 		t.Run(
 			tt.name,
 			func(t *testing.T) {
-				got := Lex(strings.NewReader(tt.input))
+				got := Lex(context.Background(), strings.NewReader(tt.input))
 				for i, tok := range tt.want {
 					g, ok := <-got
 					if !ok {
@@ -132,5 +308,278 @@ func TestLex(t *testing.T) {
 			},
 		)
 	}
+}
 
+func TestScanner(t *testing.T) {
+	for _, tt := range lexTests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(strings.NewReader(tt.input))
+			for i, tok := range tt.want {
+				if g := s.Next(); g.Kind != tok.Kind || g.Sval != tok.Sval {
+					t.Errorf("(%d): want %v, got %v", i, tok, g)
+				}
+			}
+			// Eof forever
+			if g := s.Next(); g.Kind != kind.Eof {
+				t.Errorf("want Eof after the end, got %v", g)
+			}
+		})
+	}
+}
+
+// endless reads "1 " forever.
+type endless struct{}
+
+func (endless) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = " 1"[i%2]
+	}
+	return len(p), nil
+}
+
+func TestLexCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := Lex(ctx, endless{})
+	<-ch
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("lexer goroutine didn't exit after cancel")
+	}
+}
+
+func TestLexPos(t *testing.T) {
+	input := "main() {\n\t1 +\n  x\n}"
+	want := []struct {
+		sval       string
+		start, end Pos
+	}{
+		{"main", Pos{"a.lang", 0, 1, 1}, Pos{"a.lang", 4, 1, 5}},
+		{"(", Pos{"a.lang", 4, 1, 5}, Pos{"a.lang", 5, 1, 6}},
+		{")", Pos{"a.lang", 5, 1, 6}, Pos{"a.lang", 6, 1, 7}},
+		{"{", Pos{"a.lang", 7, 1, 8}, Pos{"a.lang", 8, 1, 9}},
+		{"1", Pos{"a.lang", 10, 2, 2}, Pos{"a.lang", 11, 2, 3}},
+		{"+", Pos{"a.lang", 12, 2, 4}, Pos{"a.lang", 13, 2, 5}},
+		{"x", Pos{"a.lang", 16, 3, 3}, Pos{"a.lang", 17, 3, 4}},
+		{"}", Pos{"a.lang", 18, 4, 1}, Pos{"a.lang", 19, 4, 2}},
+		{"", Pos{"a.lang", 19, 4, 2}, Pos{"a.lang", 19, 4, 2}},
+	}
+	got := Lex(context.Background(), strings.NewReader(input), WithFile("a.lang"))
+	for i, w := range want {
+		g, ok := <-got
+		if !ok {
+			t.Fatalf("closed before finish")
+		}
+		if g.Sval != w.sval || g.Start != w.start || g.End != w.end {
+			t.Errorf("(%d): want %q %v-%v, got %q %v-%v", i, w.sval, w.start, w.end, g.Sval, g.Start, g.End)
+		}
+	}
+}
+
+func TestStreamPos(t *testing.T) {
+	s := NewStream(Lex(context.Background(), strings.NewReader("1 +\n2")))
+	if got, want := s.Pos(2).String(), "2:1"; got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+	if got, want := s.Pos(10).String(), "2:2"; got != want {
+		t.Errorf("want %s, got %s at eof", want, got)
+	}
+}
+
+func TestLexReadError(t *testing.T) {
+	r := io.MultiReader(strings.NewReader("1 +"), iotest.ErrReader(errors.New("boom")))
+	s := NewStream(Lex(context.Background(), r))
+	want := []kind.Kind{kind.Integer, kind.Plus, kind.Invalid, kind.Eof}
+	for i, k := range want {
+		if g := s.Look(i); g == nil || g.Kind != k {
+			t.Fatalf("(%d): want %v, got %v", i, k, g)
+		}
+	}
+	errs := s.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("want 1 error, got %v", errs)
+	}
+	if got, want := errs.Error(), "1:4: read error: boom"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestLexError(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string // error messages
+	}{
+		{"unexpected character", "1 # 2 @", []string{
+			"1:3: unexpected character '#'",
+			"1:7: unexpected character '@'",
+		}},
+		{"unterminated block comment", "1 /* /* */", []string{
+			"1:3: unterminated block comment",
+		}},
+		{"invalid integer", "0b102 0o8 0xg 12abc 1__0 1_ 18446744073709551616 1u7", []string{
+			"1:1: invalid digit '2' in binary literal",
+			"1:7: invalid digit '8' in octal literal",
+			`1:11: invalid suffix "g" on integer literal`,
+			`1:15: invalid suffix "abc" on integer literal`,
+			"1:21: '_' must separate successive digits",
+			"1:26: '_' must separate successive digits",
+			"1:29: integer literal too large",
+			`1:50: invalid suffix "u7" on integer literal`,
+		}},
+		{"invalid float", "1.5f32 1_.5 1e_3 1e999", []string{
+			`1:1: invalid suffix "f32" on floating-point literal`,
+			"1:8: '_' must separate successive digits",
+			`1:13: invalid suffix "e_3" on integer literal`,
+			"1:18: floating-point literal out of range",
+		}},
+		{"invalid char", "'' 'ab' '\\q' '\\a\n'", []string{
+			"1:1: empty character literal",
+			"1:4: character literal has more than one character",
+			`1:9: unknown escape sequence '\q'`,
+			"1:14: unterminated character literal",
+			"2:1: unterminated character literal",
+		}},
+		{"invalid byte", "b'\\u{100}' b'' b'a", []string{
+			"1:1: character 'Ā' does not fit in a byte",
+			"1:12: empty character literal",
+			"1:16: unterminated character literal",
+		}},
+		{"unterminated string", "\"abc\n\"abc\\\"", []string{
+			"1:1: unterminated string literal",
+			"2:1: unterminated string literal",
+		}},
+		{"invalid escape", `"\q" "\u{}" "\u{110000}" "\u3b1" 1`, []string{
+			`1:1: unknown escape sequence '\q'`,
+			`1:6: unicode escape must have 1 to 6 hex digits`,
+			`1:13: invalid unicode character '\u{110000}'`,
+			`1:26: expected '{' after '\u'`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := NewStream(Lex(context.Background(), strings.NewReader(tt.input))).Errors()
+			if len(errs) != len(tt.want) {
+				t.Fatalf("want %d errors, got %v", len(tt.want), errs)
+			}
+			for i, err := range errs {
+				if err.Error() != tt.want[i] {
+					t.Errorf("(%d): want %q, got %q", i, tt.want[i], err)
+				}
+			}
+		})
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`""`, ""},
+		{`"abc"`, "abc"},
+		{`"a\nb\tc"`, "a\nb\tc"},
+		{`"\\\"\0"`, "\\\"\x00"},
+		{`"\u{3b1}\u{1F600}"`, "\u03b1\U0001F600"},
+		{`"café"`, "café"},
+	}
+	for _, tt := range tests {
+		got, err := Unquote(tt.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: want %q, got %q", tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestParseInt(t *testing.T) {
+	tests := []struct {
+		input  string
+		want   uint64
+		suffix string
+	}{
+		{"0", 0, ""},
+		{"42", 42, ""},
+		{"007", 7, ""},
+		{"0xDead_Beef", 0xdeadbeef, ""},
+		{"0b1111_0000u8", 0xf0, "u8"},
+		{"0o777i16", 0o777, "i16"},
+		{"18446744073709551615u64", 1<<64 - 1, "u64"},
+	}
+	for _, tt := range tests {
+		got, suffix, err := ParseInt(tt.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.input, err)
+			continue
+		}
+		if got != tt.want || suffix != tt.suffix {
+			t.Errorf("%s: want %d %q, got %d %q", tt.input, tt.want, tt.suffix, got, suffix)
+		}
+	}
+}
+
+func TestLexTrivia(t *testing.T) {
+	input := "/* head */ main() { // open\n\t1 +  2 /* two\n */ \n}\n"
+	want := []struct {
+		leading, sval, trailing string
+	}{
+		{"/* head */ ", "main", ""},
+		{"", "(", ""},
+		{"", ")", " "},
+		{"", "{", " // open"},
+		{"\n\t", "1", " "},
+		{"", "+", "  "},
+		{"", "2", " /* two\n */ "},
+		{"\n", "}", ""},
+		{"\n", "", ""},
+	}
+	s := NewScanner(strings.NewReader(input), WithTrivia())
+	for i, w := range want {
+		g := s.Next()
+		if g.Leading != w.leading || g.Sval != w.sval || g.Trailing != w.trailing {
+			t.Errorf("(%d): want %q %q %q, got %q %q %q", i, w.leading, w.sval, w.trailing, g.Leading, g.Sval, g.Trailing)
+		}
+	}
+}
+
+func TestLexRoundTrip(t *testing.T) {
+	inputs := []string{
+		"",
+		"  \n\t ",
+		"1 # 2 /* unterminated",
+		"\"unterminated\n\"\\q\" 0b12",
+		"a\xffb \xc3",
+		"/// doc\r\nmain(){ 1 }\r\n",
+	}
+	for _, tt := range lexTests {
+		inputs = append(inputs, tt.input)
+	}
+	for _, input := range inputs {
+		var b strings.Builder
+		s := NewScanner(strings.NewReader(input), WithTrivia())
+		for {
+			tok := s.Next()
+			if src := input[tok.Start.Offset:tok.End.Offset]; src != tok.Sval {
+				t.Errorf("%q: want Sval %q at %v, got %q", input, src, tok.Start, tok.Sval)
+			}
+			b.WriteString(tok.FullText())
+			if tok.Kind == kind.Eof {
+				break
+			}
+		}
+		if b.String() != input {
+			t.Errorf("want %q, got %q", input, b.String())
+		}
+	}
 }
@@ -0,0 +1,233 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var errUnterminatedEscape = errors.New("unterminated escape sequence")
+
+// Unquote interprets a double-quoted string literal and returns its value.
+func Unquote(lit string) (string, error) {
+	if len(lit) < 2 || lit[0] != '"' || lit[len(lit)-1] != '"' {
+		return "", errors.New("invalid string literal")
+	}
+	s := lit[1 : len(lit)-1]
+	var b strings.Builder
+	for len(s) > 0 {
+		r, tail, err := unquoteChar(s, '"')
+		if err != nil {
+			return "", err
+		}
+		b.WriteRune(r)
+		s = tail
+	}
+	return b.String(), nil
+}
+
+// UnquoteChar interprets a single-quoted character literal and returns its value.
+func UnquoteChar(lit string) (rune, error) {
+	if len(lit) < 2 || lit[0] != '\'' || lit[len(lit)-1] != '\'' {
+		return 0, errors.New("invalid character literal")
+	}
+	s := lit[1 : len(lit)-1]
+	if s == "" {
+		return 0, errors.New("empty character literal")
+	}
+	r, tail, err := unquoteChar(s, '\'')
+	if err != nil {
+		return 0, err
+	}
+	if tail != "" {
+		return 0, errors.New("character literal has more than one character")
+	}
+	return r, nil
+}
+
+// UnquoteByte interprets a byte literal such as b'a' and returns its value.
+func UnquoteByte(lit string) (byte, error) {
+	if len(lit) < 1 || lit[0] != 'b' {
+		return 0, errors.New("invalid byte literal")
+	}
+	r, err := UnquoteChar(lit[1:])
+	if err != nil {
+		return 0, err
+	}
+	if r > 0xff {
+		return 0, fmt.Errorf("character %q does not fit in a byte", r)
+	}
+	return byte(r), nil
+}
+
+// unquoteChar decodes the first character or escape sequence in s.
+// The quote character must be escaped.
+func unquoteChar(s string, quote byte) (r rune, tail string, err error) {
+	if s[0] == quote {
+		return 0, "", fmt.Errorf("unescaped %q", quote)
+	}
+	if s[0] != '\\' {
+		r, size := utf8.DecodeRuneInString(s)
+		return r, s[size:], nil
+	}
+	if len(s) < 2 {
+		return 0, "", errUnterminatedEscape
+	}
+	c, size := utf8.DecodeRuneInString(s[1:])
+	s = s[1+size:]
+	switch c {
+	case 'n':
+		return '\n', s, nil
+	case 't':
+		return '\t', s, nil
+	case '0':
+		return 0, s, nil
+	case '\\', '\'', '"':
+		return c, s, nil
+	case 'u':
+		return unquoteUnicode(s)
+	}
+	return 0, "", fmt.Errorf("unknown escape sequence '\\%c'", c)
+}
+
+// unquoteUnicode decodes "{XXXX}" following "\u".
+func unquoteUnicode(s string) (rune, string, error) {
+	if len(s) == 0 || s[0] != '{' {
+		return 0, "", errors.New("expected '{' after '\\u'")
+	}
+	end := strings.IndexByte(s, '}')
+	if end < 0 {
+		return 0, "", errUnterminatedEscape
+	}
+	hex := s[1:end]
+	if len(hex) == 0 || len(hex) > 6 {
+		return 0, "", errors.New("unicode escape must have 1 to 6 hex digits")
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid unicode escape '\\u{%s}'", hex)
+	}
+	r := rune(v)
+	if !utf8.ValidRune(r) {
+		return 0, "", fmt.Errorf("invalid unicode character '\\u{%s}'", hex)
+	}
+	return r, s[end+1:], nil
+}
+
+// IntSuffixes are the width suffixes of integer literals.
+var IntSuffixes = []string{"i8", "i16", "i32", "i64", "u8", "u16", "u32", "u64"}
+
+// ParseInt interprets an integer literal such as "0xff_ffu16".
+// It returns the value and the width suffix, which is empty if absent.
+func ParseInt(lit string) (uint64, string, error) {
+	base, name, body := 10, "decimal", lit
+	if len(lit) >= 2 && lit[0] == '0' {
+		switch lit[1] {
+		case 'x':
+			base, name, body = 16, "hexadecimal", lit[2:]
+		case 'o':
+			base, name, body = 8, "octal", lit[2:]
+		case 'b':
+			base, name, body = 2, "binary", lit[2:]
+		}
+	}
+
+	// the suffix starts at the first letter which can't be a digit
+	limit := 10
+	if base == 16 {
+		limit = 16
+	}
+	end := 0
+	for end < len(body) && (body[end] == '_' || digitVal(rune(body[end])) < limit) {
+		end++
+	}
+	digits, suffix := body[:end], body[end:]
+	if suffix != "" && !isIntSuffix(suffix) {
+		return 0, "", fmt.Errorf("invalid suffix %q on integer literal", suffix)
+	}
+	if digits == "" {
+		return 0, "", fmt.Errorf("%s literal has no digits", name)
+	}
+	for i, c := range digits {
+		if c == '_' {
+			if i == 0 || i == len(digits)-1 || digits[i+1] == '_' {
+				return 0, "", errors.New("'_' must separate successive digits")
+			}
+			continue
+		}
+		if digitVal(c) >= base {
+			return 0, "", fmt.Errorf("invalid digit %q in %s literal", c, name)
+		}
+	}
+	v, err := strconv.ParseUint(strings.ReplaceAll(digits, "_", ""), base, 64)
+	if err != nil {
+		return 0, "", errors.New("integer literal too large")
+	}
+	return v, suffix, nil
+}
+
+// ParseFloat interprets a floating-point literal such as "1_000.5e-3f64".
+func ParseFloat(lit string) (float64, error) {
+	// split into digits, fraction, exponent and suffix
+	i := 0
+	scan := func() string {
+		start := i
+		for i < len(lit) && isDecimal(rune(lit[i])) {
+			i++
+		}
+		return lit[start:i]
+	}
+	parts := []string{scan()}
+	if i < len(lit) && lit[i] == '.' {
+		i++
+		parts = append(parts, scan())
+	}
+	if i < len(lit) && (lit[i] == 'e' || lit[i] == 'E') {
+		i++
+		if i < len(lit) && (lit[i] == '+' || lit[i] == '-') {
+			i++
+		}
+		parts = append(parts, scan())
+	}
+	num, suffix := lit[:i], lit[i:]
+	if suffix != "" && suffix != "f64" {
+		return 0, fmt.Errorf("invalid suffix %q on floating-point literal", suffix)
+	}
+	for _, digits := range parts {
+		if digits == "" {
+			return 0, errors.New("floating-point literal has missing digits")
+		}
+		if digits[0] == '_' || digits[len(digits)-1] == '_' || strings.Contains(digits, "__") {
+			return 0, errors.New("'_' must separate successive digits")
+		}
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(num, "_", ""), 64)
+	if err != nil {
+		return 0, errors.New("floating-point literal out of range")
+	}
+	return v, nil
+}
+
+func isIntSuffix(s string) bool {
+	for _, suffix := range IntSuffixes {
+		if s == suffix {
+			return true
+		}
+	}
+	return false
+}
+
+// digitVal returns the value of the hexadecimal digit, or 16 if c is not a digit.
+func digitVal(c rune) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'f':
+		return int(c - 'a' + 10)
+	case 'A' <= c && c <= 'F':
+		return int(c - 'A' + 10)
+	}
+	return 16
+}
@@ -0,0 +1,117 @@
+package token
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/lunashade/lang/internal/token/kind"
+)
+
+// Edit replaces the bytes in [Start, End) of the old source with Text.
+type Edit struct {
+	Start, End int
+	Text       string
+}
+
+// Relex returns the tokens of the edited source without lexing all of it.
+// src is the source after the edit, old is the complete token list of
+// the source before it, lexed with the same options.
+//
+// It re-scans from the token before the edit, and stops at the first
+// token after the edit which matches an old one. The old tokens from
+// there are reused with their positions shifted.
+func Relex(src []byte, old []Token, e Edit, opts ...Option) []Token {
+	// restart from the token before the first one touching the edit,
+	// since the lexer looks ahead across at most one token boundary
+	k := 0
+	for k < len(old) && old[k].End.Offset < e.Start {
+		k++
+	}
+	if k > 0 {
+		k--
+	}
+	start := Pos{Offset: 0, Line: 1, Col: 1}
+	if k > 0 {
+		prev := old[k-1]
+		start = advance(prev.End, prev.Trailing)
+	} else if len(old) > 0 {
+		start.File = old[0].Start.File
+	}
+
+	delta := len(e.Text) - (e.End - e.Start)
+	editEnd := e.Start + len(e.Text) // end of the edit in the new source
+
+	opts = append(opts, startAt(start))
+	s := NewScanner(bytes.NewReader(src[start.Offset:]), opts...)
+	tokens := append([]Token(nil), old[:k]...)
+	j := k // candidate of the old token to resync
+	for {
+		tok := s.Next()
+		if full := tok.Start.Offset - len(tok.Leading); full >= editEnd {
+			for j < len(old) && old[j].Start.Offset+delta < tok.Start.Offset {
+				j++
+			}
+			if j < len(old) && same(&old[j], &tok, delta) {
+				return append(tokens, shift(old[j:], tok.Start, old[j].Start, delta)...)
+			}
+		}
+		tokens = append(tokens, tok)
+		if tok.Kind == kind.Eof {
+			return tokens
+		}
+	}
+}
+
+// startAt starts lexing at the position, for a source sliced at it.
+func startAt(pos Pos) Option {
+	return func(l *lexer) {
+		l.pos = pos
+	}
+}
+
+// same reports whether the new token is the old one moved by delta bytes.
+func same(old, tok *Token, delta int) bool {
+	return old.Start.Offset+delta == tok.Start.Offset &&
+		old.Kind == tok.Kind &&
+		old.Sval == tok.Sval &&
+		old.Leading == tok.Leading
+}
+
+// shift moves the tokens so that the first one starts at to instead of from.
+// Columns change only on the line of the first token.
+func shift(tokens []Token, to, from Pos, delta int) []Token {
+	lines := to.Line - from.Line
+	cols := to.Col - from.Col
+	move := func(p Pos) Pos {
+		if p.Line == from.Line {
+			p.Col += cols
+		}
+		p.Line += lines
+		p.Offset += delta
+		return p
+	}
+	res := make([]Token, len(tokens))
+	for i, tok := range tokens {
+		tok.Start = move(tok.Start)
+		tok.End = move(tok.End)
+		if tok.Err != nil {
+			err := *tok.Err
+			err.Pos = tok.Start
+			tok.Err = &err
+		}
+		res[i] = tok
+	}
+	return res
+}
+
+// advance returns the position after the text at p.
+func advance(p Pos, text string) Pos {
+	p.Offset += len(text)
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		p.Line += strings.Count(text, "\n")
+		p.Col = len(text) - i
+	} else {
+		p.Col += len(text)
+	}
+	return p
+}
@@ -0,0 +1,86 @@
+package token
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/lunashade/lang/internal/token/kind"
+)
+
+func lexAll(src string, opts ...Option) []Token {
+	s := NewScanner(strings.NewReader(src), opts...)
+	var tokens []Token
+	for {
+		tok := s.Next()
+		tokens = append(tokens, tok)
+		if tok.Kind == kind.Eof {
+			return tokens
+		}
+	}
+}
+
+func TestRelex(t *testing.T) {
+	sources := []string{
+		"main() {\n\t1 + 2.5 // sum\n}\n",
+		"/* a\n */ f(){ \"s\" 'c' }",
+		"a /b 1. x",
+	}
+	texts := []string{"", "1", "x", ".", "/", "*", "\n", "/*", "*/", "\"", "'", " 2e"}
+	modes := map[string][]Option{
+		"plain":  {WithFile("a.lang")},
+		"trivia": {WithFile("a.lang"), WithTrivia()},
+	}
+	for name, opts := range modes {
+		for _, src := range sources {
+			old := lexAll(src, opts...)
+			for start := 0; start <= len(src); start++ {
+				for end := start; end <= len(src) && end <= start+3; end++ {
+					for _, text := range texts {
+						e := Edit{Start: start, End: end, Text: text}
+						edited := src[:start] + text + src[end:]
+						want := lexAll(edited, opts...)
+						got := Relex([]byte(edited), old, e, opts...)
+						assert.DeepEqual(t, want, got)
+						if t.Failed() {
+							t.Fatalf("%s: %q with %+v", name, src, e)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRelexReuse(t *testing.T) {
+	src := "main() {\n" + strings.Repeat("\t1 + 2;\n", 100) + "}\n"
+	old := lexAll(src)
+	// change the first "1" to "10"
+	at := strings.Index(src, "1")
+	edited := src[:at] + "10" + src[at+1:]
+	got := Relex([]byte(edited), old, Edit{Start: at, End: at + 1, Text: "10"})
+	assert.DeepEqual(t, lexAll(edited), got)
+	// tokens after the edit are the old ones moved
+	if got[8].Sval != "1" || got[8].Start.Offset != old[8].Start.Offset+1 || got[8].Start.Line != 3 {
+		t.Errorf("want moved token, got %v", got[8])
+	}
+}
+
+func BenchmarkRelex(b *testing.B) {
+	src := "main() {\n" + strings.Repeat("\t1 + 2;\n", 10000) + "}\n"
+	old := lexAll(src)
+	at := strings.Index(src, "1")
+	edited := []byte(src[:at] + "10" + src[at+1:])
+	e := Edit{Start: at, End: at + 1, Text: "10"}
+	b.Run("Relex", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Relex(edited, old, e)
+		}
+	})
+	b.Run("Lex", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			lexAll(string(edited))
+		}
+	})
+}
@@ -1,6 +1,12 @@
 package token
 
-import "github.com/lunashade/lang/internal/token/kind"
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lunashade/lang/internal/token/kind"
+)
 
 type stateFn func(*lexer) stateFn
 
@@ -10,54 +16,275 @@ func lexSkip(l *lexer) stateFn {
 		if isDigit(c) {
 			return lexNumber
 		}
+		if c == '/' {
+			return lexSlash
+		}
+		if c == '"' {
+			return lexString
+		}
+		if c == '\'' {
+			return lexChar
+		}
+		if c == 'b' && l.peekAt(1) == '\'' {
+			return lexByte
+		}
 		if isSymbol(c) {
 			return lexSymbol
 		}
-		if isIdent(c) {
+		if isIdentStart(c) {
 			return lexIdent
 		}
 		if l.next() == eof {
 			break
 		}
+		l.buf = append(l.buf, c)
+		if isRawByte(c) {
+			l.errorf("invalid UTF-8 encoding")
+			continue
+		}
+		if !unicode.IsSpace(c) {
+			l.errorf("unexpected character %q", c)
+			continue
+		}
+		l.ignore()
+	}
+	if l.err != nil {
+		l.errorf("read error: %v", l.err)
+		l.err = nil
 	}
 	l.emit(kind.Eof)
 	return nil
 }
 
+// lexNumber consume number literal.
+// Integers have optional base prefix, digit separators and width suffix.
+// Floats are decimal with fraction or exponent, or with "f64" suffix.
 func lexNumber(l *lexer) stateFn {
+	k := kind.Integer
+	if l.peekAt(0) == '0' && strings.IndexByte("xob", l.peekAt(1)) >= 0 {
+		// digits are checked by ParseInt
+		l.acceptRun(isIdentContinue)
+	} else {
+		l.acceptRun(isDecimal)
+		if l.peekAt(0) == '.' && isDigit(rune(l.peekAt(1))) {
+			k = kind.Float
+			l.buf = append(l.buf, l.next())
+			l.acceptRun(isDecimal)
+		}
+		if c := l.peekAt(0); c == 'e' || c == 'E' {
+			n := 1
+			if sign := l.peekAt(1); sign == '+' || sign == '-' {
+				n = 2
+			}
+			if isDigit(rune(l.peekAt(n))) {
+				k = kind.Float
+				for i := 0; i < n; i++ {
+					l.buf = append(l.buf, l.next())
+				}
+				l.acceptRun(isDecimal)
+			}
+		}
+		// suffix
+		l.acceptRun(isIdentContinue)
+		if strings.HasSuffix(l.text(), "f64") {
+			k = kind.Float
+		}
+	}
+
+	var err error
+	if k == kind.Float {
+		_, err = ParseFloat(l.text())
+	} else {
+		_, _, err = ParseInt(l.text())
+	}
+	if err != nil {
+		l.errorf("%v", err)
+		return lexSkip
+	}
+	l.emit(k)
+	return lexSkip
+}
+
+// lexString consume double-quoted string literal.
+// It must be closed on the same line.
+func lexString(l *lexer) stateFn {
+	if !l.quoted('"') {
+		l.errorf("unterminated string literal")
+		return lexSkip
+	}
+	if _, err := Unquote(l.text()); err != nil {
+		l.errorf("%v", err)
+		return lexSkip
+	}
+	l.emit(kind.String)
+	return lexSkip
+}
+
+// lexChar consume single-quoted character literal,
+// or a label such as 'outer, which has no closing quote.
+func lexChar(l *lexer) stateFn {
+	if isLabel(l) {
+		l.buf = append(l.buf, l.next())
+		l.acceptRun(isIdentContinue)
+		l.emit(kind.Label)
+		return lexSkip
+	}
+	if !l.quoted('\'') {
+		l.errorf("unterminated character literal")
+		return lexSkip
+	}
+	if _, err := UnquoteChar(l.text()); err != nil {
+		l.errorf("%v", err)
+		return lexSkip
+	}
+	l.emit(kind.Char)
+	return lexSkip
+}
+
+// lexByte consume byte literal such as b'a', which is a character literal
+// prefixed by 'b' with a code point up to 0xff.
+func lexByte(l *lexer) stateFn {
+	l.buf = append(l.buf, l.next())
+	if !l.quoted('\'') {
+		l.errorf("unterminated character literal")
+		return lexSkip
+	}
+	if _, err := UnquoteByte(l.text()); err != nil {
+		l.errorf("%v", err)
+		return lexSkip
+	}
+	l.emit(kind.Char)
+	return lexSkip
+}
+
+// isLabel reports whether the quote starts a label, an ASCII identifier
+// not followed by the closing quote.
+func isLabel(l *lexer) bool {
+	if c := rune(l.peekAt(1)); c >= utf8.RuneSelf || !isIdentStart(c) {
+		return false
+	}
+	n := 2
+	for c := rune(l.peekAt(n)); c < utf8.RuneSelf && isIdentContinue(c); c = rune(l.peekAt(n)) {
+		n++
+	}
+	return l.peekAt(n) != '\''
+}
+
+// quoted consumes text quoted by q, skipping escaped characters.
+// It reports false if the line or input ends before the closing quote.
+func (l *lexer) quoted(q rune) bool {
+	l.buf = append(l.buf, l.next())
+	for {
+		c := l.next()
+		if c == eof || c == '\n' {
+			l.backup()
+			return false
+		}
+		l.buf = append(l.buf, c)
+		if c == q {
+			return true
+		}
+		if c == '\\' && l.peek() != '\n' && l.peek() != eof {
+			l.buf = append(l.buf, l.next())
+		}
+	}
+}
+
+func lexIdent(l *lexer) stateFn {
 	for {
 		c := l.next()
-		if !isDigit(c) {
+		if !isIdentContinue(c) {
 			break
 		}
 		l.buf = append(l.buf, c)
 	}
 	l.backup()
-	l.emit(kind.Integer)
+
+	k := kind.KeywordKind(l.text())
+	l.emit(k)
 	return lexSkip
 }
-func lexIdent(l *lexer) stateFn {
+
+// lexSlash consume comments, or a symbol starting with '/'.
+func lexSlash(l *lexer) stateFn {
+	l.buf = append(l.buf, l.next())
+	switch l.peek() {
+	case '/':
+		return lexLineComment
+	case '*':
+		return lexBlockComment
+	}
+	return lexSymbolRest
+}
+
+// lexLineComment consume "//" comment until the end of line.
+// "///" (but not "////") starts a doc comment, which is emitted.
+func lexLineComment(l *lexer) stateFn {
+	l.buf = append(l.buf, l.next())
+	doc := false
+	if l.peek() == '/' {
+		l.buf = append(l.buf, l.next())
+		doc = l.peek() != '/'
+	}
 	for {
 		c := l.next()
-		if !isIdent(c) {
+		if c == '\n' || c == eof {
 			break
 		}
 		l.buf = append(l.buf, c)
 	}
 	l.backup()
+	if doc {
+		l.emit(kind.DocComment)
+	} else {
+		l.ignore()
+	}
+	return lexSkip
+}
 
-	k := kind.KeywordKind(string(l.buf))
-	l.emit(k)
+// lexBlockComment consume "/* */" comment, which may be nested.
+func lexBlockComment(l *lexer) stateFn {
+	l.buf = append(l.buf, l.next())
+	depth := 1
+	for depth > 0 {
+		c := l.next()
+		if c == eof {
+			l.errorf("unterminated block comment")
+			return lexSkip
+		}
+		l.buf = append(l.buf, c)
+		switch {
+		case c == '/' && l.peek() == '*':
+			l.buf = append(l.buf, l.next())
+			depth++
+		case c == '*' && l.peek() == '/':
+			l.buf = append(l.buf, l.next())
+			depth--
+		}
+	}
+	l.ignore()
 	return lexSkip
 }
 
-// lexSymbol consume punctuation symbol with single character
+// lexSymbol consume punctuation symbol.
+// It takes the longest operator (maximal munch), or a single character.
 func lexSymbol(l *lexer) stateFn {
-	c := l.next()
-	l.buf = append(l.buf, c)
+	l.buf = append(l.buf, l.next())
+	return lexSymbolRest
+}
+
+// lexSymbolRest consume the rest of the symbol whose first character is in buf.
+func lexSymbolRest(l *lexer) stateFn {
+	c := l.buf[0]
+	if k := kind.OperatorKind(string(c) + string(l.peek())); k != kind.Invalid {
+		l.buf = append(l.buf, l.next())
+		l.emit(k)
+		return lexSkip
+	}
 	k := kind.SymbolKind(c)
 	if k == kind.Invalid {
-		panic("unknown symbol")
+		l.errorf("unknown symbol %q", c)
+		return lexSkip
 	}
 	l.emit(k)
 	return lexSkip
@@ -6,23 +6,47 @@ import (
 	"github.com/lunashade/lang/internal/token/kind"
 )
 
+// Source supplies tokens one by one, such as Scanner.
+type Source interface {
+	Next() Token
+}
+
+type chanSource <-chan Token
+
+// Next returns Eof if the channel is closed before Eof.
+func (ch chanSource) Next() Token {
+	tok, ok := <-ch
+	if !ok {
+		return Token{Kind: kind.Eof}
+	}
+	return tok
+}
+
 type Stream struct {
-	ch     chan Token
+	src    Source
 	tokens []Token
 	ateof  bool
+	errs   ErrorList
 }
 
-func NewStream(ch chan Token) *Stream { return &Stream{ch: ch} }
+// NewStream reads tokens from the channel returned by Lex.
+func NewStream(ch <-chan Token) *Stream { return &Stream{src: chanSource(ch)} }
+
+// NewSourceStream reads tokens from the source.
+func NewSourceStream(src Source) *Stream { return &Stream{src: src} }
 
 var ErrAtEof = errors.New("at eof")
 
-// fetch reads next token from channel
+// fetch reads next token from source
 func (p *Stream) fetch() error {
 	if p.ateof {
 		return ErrAtEof
 	}
-	tok := <-p.ch
+	tok := p.src.Next()
 	p.tokens = append(p.tokens, tok)
+	if tok.Kind == kind.Invalid && tok.Err != nil {
+		p.errs = append(p.errs, tok.Err)
+	}
 	if tok.Kind == kind.Eof {
 		p.ateof = true
 		return ErrAtEof
@@ -32,17 +56,13 @@ func (p *Stream) fetch() error {
 
 // look at the token at the position
 func (p *Stream) Look(at int) *Token {
-	if at < len(p.tokens) {
-		return &(p.tokens[at])
-	}
-	for {
-		err := p.fetch()
-		if err != nil {
+	for at >= len(p.tokens) {
+		if err := p.fetch(); err != nil {
 			break
 		}
-		if at < len(p.tokens) {
-			return &(p.tokens[at])
-		}
+	}
+	if at < len(p.tokens) {
+		return &(p.tokens[at])
 	}
 	return nil
 }
@@ -51,3 +71,22 @@ func (p *Stream) Complete() bool {
 	p.fetch()
 	return p.ateof
 }
+
+// Pos returns the start position of the token at the position.
+// At or past the end of input, it returns the position of the Eof token.
+func (p *Stream) Pos(at int) Pos {
+	if t := p.Look(at); t != nil {
+		return t.Start
+	}
+	if len(p.tokens) == 0 {
+		return Pos{}
+	}
+	return p.tokens[len(p.tokens)-1].Start
+}
+
+// Errors reads the rest of the input and returns every lexical error in it.
+func (p *Stream) Errors() ErrorList {
+	for p.fetch() == nil {
+	}
+	return p.errs
+}
@@ -1,19 +1,54 @@
 package token
 
 import (
+	"fmt"
+
 	"github.com/lunashade/lang/internal/token/kind"
 )
 
 var INVALID = Token{Kind: kind.Invalid}
 
+// Pos is a position in the source.
+type Pos struct {
+	File   string // file name, may be empty
+	Offset int    // byte offset, starting at 0
+	Line   int    // line number, starting at 1
+	Col    int    // column number in bytes, starting at 1
+}
+
+// String returns "file:line:col", or "line:col" if the file name is empty.
+func (p Pos) String() string {
+	if p.File == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Col)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Col)
+}
+
+// Token is a lexical token.
+// Sval is the source text in [Start, End).
+// Err is set only on kind.Invalid tokens.
+// Leading and Trailing are whitespace and comments around the token,
+// kept only in trivia mode. Trailing stops before the next newline.
 type Token struct {
-	Kind kind.Kind
-	Sval string
+	Kind     kind.Kind
+	Sval     string
+	Start    Pos
+	End      Pos
+	Err      *Error
+	Leading  string
+	Trailing string
+}
+
+// FullText returns the source text of the token with its trivia.
+func (t *Token) FullText() string {
+	return t.Leading + t.Sval + t.Trailing
 }
 
-func makeToken(kind kind.Kind, sval string) Token {
+func makeToken(kind kind.Kind, sval string, start, end Pos) Token {
 	return Token{
-		Kind: kind,
-		Sval: sval,
+		Kind:  kind,
+		Sval:  sval,
+		Start: start,
+		End:   end,
 	}
 }
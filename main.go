@@ -1,12 +1,25 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"os"
 
 	"github.com/lunashade/lang/internal/compile"
+	"github.com/lunashade/lang/internal/parse"
 )
 
 func main() {
-	compile.Run(os.Stdin, os.Stdout)
-	return
+	if err := compile.Run(os.Stdin, os.Stdout); err != nil {
+		// every error of the list on its own line
+		var errs parse.ErrorList
+		if errors.As(err, &errs) {
+			for _, e := range errs {
+				fmt.Fprintf(os.Stderr, "parse error: %s\n", e)
+			}
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
 }
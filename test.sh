@@ -53,6 +53,93 @@ function main {
     check 20 "main(){ if 1==0 then 5*5 else 5*5-5 }"
     check 0 "main(){ if 1==0 then 5*5 }"
     check 25 "main() {if {if 1 then 1 else 0} then {if 1 then 25 else 0} else {if 1 then 0 else 0}}"
+    check 3 "main(){ 1 + /* one /* nested */ */ 2 // sum
+}"
+    check 7 "/// doc comment
+main(){ 7 }"
+    check 4 'main(){ "hello\n"; "\u{3b1}\0"; 4 }'
+    check 255 "main(){ 0xff }"
+    check 25 "main(){ 0b1010 + 0o17 }"
+    check 10 "main(){ 1_000 / 100 }"
+    check 1 "main(){ 255u8 == 0xffu8 }"
+    check 3 "main(){ (1.5 * 2.0) as i32 }"
+    check 2 "main(){ (2e-3 * 1000.0) as i32 }"
+    check 1 "main(){ 1.5 < 2.5 }"
+    check 3 "main(){ (7 as f64 / 2.0) as i32 }"
+    check 1 "main(){ if 0.5 then 1 else 2 }"
+    check 44 "main(){ 300 as u8 as i32 }"
+    check 97 "main(){ 'a' }"
+    check 10 "main(){ '\\n' }"
+    check 1 "main(){ 'b' - 'a' }"
+    check 45 "main(){ '\\u{3b1}' - 900 }"
+    check 5 "main(){10-3-2}"
+    check 2 "main(){100/10/5}"
+    check 3 "main(){1-2*3+8}"
+    check 3 "main(){ let x = 1; let y = 2; x + y }"
+    check 9 "main(){ let x = 3; x = x * 3; x }"
+    check 4 "main(){ let a = 1; let b = a = 2; a + b }"
+    check 1 "main(){ let x = 1; { let x = 10; x = x + 1; }; x }"
+    check 11 "main(){ let x = 1; let x = x + 10; x }"
+    check 5 "main(){ let a = if 1 then { let b = 5; b } else 2; a }"
+    check 120 "fact(n: i32) -> i32 { if n <= 1 then 1 else n * fact(n - 1) } main(){ fact(5) }"
+    check 55 "main(){ fib(10) } fib(n: i32) { if n < 2 then n else fib(n - 1) + fib(n - 2) }"
+    check 7 "add(a: i32, b: i32) { a + b } main(){ add(3, 4) }"
+    check 4 "half(x: f64) -> f64 { x / 2.0 } main(){ half(9.0) as i32 }"
+    check 6 "inc(x: i32) { x = x + 1; x } main(){ let x = 5; inc(x) }"
+    check 0 "nothing() {} main(){ nothing() }"
+    check 4 "main(){ return 4; 5 }"
+    check 55 "fib(n: i32) -> i32 { if n < 2 then { return n; } else 0; return fib(n - 1) + fib(n - 2); } main(){ fib(10) }"
+    check 11 "sign(x: i32) -> i32 { if x < 0 then { return 0 - 1; } else { return 1; }; 5 } main(){ sign(3) + 10 }"
+    check 13 "f(x: i32) -> i32 { let y = if x then { return 7; } else 3; y * 2 } main(){ f(0) + f(1) }"
+    check 3 "nop() -> () { return; } main(){ nop(); 3 }"
+    check 0 "nop() -> () {} main(){ nop() }"
+    check 1 "main(){ { return 1; } + 2 }"
+    check 4 "f(x: i32){ x } main(){ f({ return 4; }) }"
+    check 7 "main(){ if { return 7; } then 1 else 2 }"
+    check 5 "main(){ { return 5; } as i64; 0 }"
+    check 6 "main(){ while { return 6; } {}; 0 }"
+    check 2 "main(){ let x = 1; if x then { x = 2; }; x }"
+    check 45 "main(){ let i = 0; let s = 0; while i < 10 { s = s + i; i = i + 1; }; s }"
+    check 14 "main(){ let i = 0; loop { i = i + 1; if i == 7 then { break i * 2; } else 0; } }"
+    check 5 "main(){ let i = 0; while i < 10 { i = i + 1; if i < 5 then { continue; } else 0; break; }; i }"
+    check 6 "main(){ let n = 0; let i = 0; 'outer: while i < 5 { i = i + 1; let j = 0; loop { j = j + 1; if j > i then { continue 'outer; } else 0; if i == 4 then { break 'outer; } else 0; n = n + 1; }; }; n }"
+    check 3 "f() -> i32 { loop { return 3; } } main(){ f() }"
+    check 2 "main(){ loop { break { return 2; } + 1; } }"
+    check 55 "fib(n: i32) -> i32 { let a = 0; let b = 1; while n > 0 { let t = a + b; a = b; b = t; n = n - 1; }; a } main(){ fib(10) }"
+    check 5 "main(){ -5 + 10 }"
+    check 3 "main(){ 1 - -2 }"
+    check 3 "main(){ -(-3) }"
+    check 1 "main(){ -2147483648 == 0 - 2147483647 - 1 }"
+    check 1 "main(){ -0x8000_0000_0000_0000i64 < 0i64 }"
+    check 101 "main(){ !0 + !5 * 10 + !0.0 * 100 }"
+    check 1 "main(){ ~5 + 7 }"
+    check 3 "main(){ -{ return 3; } }"
+    check 2 "main(){ (-1.5 * 2.0) as i32 + 5 }"
+    check 1 "main(){ let x = 3; -x as f64 < 0.0 }"
+    check 7 "main(){ let a = 0; if a != 0 && 10 / a > 2 then 1 else 7 }"
+    check 9 "main(){ let a = 0; if a == 0 || 10 / a > 2 then 9 else 1 }"
+    check 0 "main(){ let z = 0; 0 && 1 / z }"
+    check 1 "main(){ let z = 0; 1 || 1 / z }"
+    check 11 "main(){ (1 && 2) + (0 || 0.5) * 10 + (0 && 1) * 100 }"
+    check 1 "main(){ 1 < 2 && 3 < 4 || 0 }"
+    check 1 "main(){ { return 1; } && 2 }"
+    check 4 "main(){ 0 || { return 4; } }"
+    check 3 "main(){ let n = 0; let i = 0; while i < 10 && n < 3 { i = i + 1; n = n + 1; }; i }"
+    check 1 "main(){ 200u8 > 100u8 }"
+    check 127 "main(){ (255u8 / 2u8) as i32 }"
+    check 1 "main(){ 2147483648u32 > 0u32 }"
+    check 1 "f() -> u8 { 200u8 } main(){ if f() > 100u8 then 1 else 0 }"
+    check 1 "main(){ 0xffff_ffff_ffff_ffffu64 / 2u64 > 0x7fff_ffff_ffff_fffeu64 }"
+    check 1 "main(){ -1i8 < 1i8 }"
+    check 1 "main(){ (200u8 as i8 / 2i8) as i32 < 0 }"
+    check 200 "main(){ 200u8 as i32 }"
+    check 1 "main(){ 200u8 as i64 == 200i64 }"
+    check 1 "main(){ 3000000000u32 as f64 > 0.0 }"
+    check 3 "main(){ (3000000000.0 as u32 / 1000000000u32) as i32 }"
+    check 1 "main(){ -1 as i64 < 0i64 }"
+    check 1 "main(){ b'a' == 97u8 }"
+    check 255 "main(){ b'\\u{ff}' as i32 }"
+    check 1 "main(){ b'z' - b'a' == 25u8 }"
     echo ok
 }
 